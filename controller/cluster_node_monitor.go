@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// clusterNodeMonitorLeaseName identifies the Lease object leader election
+// uses to guarantee only one manager pod at a time is the cluster-wide
+// authority for node liveness decisions.
+const clusterNodeMonitorLeaseName = "longhorn-cluster-node-monitor"
+
+const (
+	clusterNodeMonitorLeaseDuration = 15 * time.Second
+	clusterNodeMonitorRenewDeadline = 10 * time.Second
+	clusterNodeMonitorRetryPeriod   = 2 * time.Second
+)
+
+// ClusterNodeMonitor is the leader-elected half of what used to be a single
+// NodeController: it owns Ready/Schedulable/Zone/Region conditions, the
+// per-zone partial-disruption throttle, and the Unknown->Unreachable
+// eviction timers. Every manager pod constructs one against the same
+// informers, but syncNode's cluster-wide logic only actually runs on
+// whichever instance currently holds the lease, so large clusters don't pay
+// for N managers all reconciling every Node object's conditions.
+type ClusterNodeMonitor struct {
+	nc *NodeController
+
+	namespace    string
+	controllerID string
+	kubeClient   clientset.Interface
+}
+
+// NewClusterNodeMonitor wraps nc (constructed the same way as before, via
+// NewNodeController) so its cluster-wide sync logic is gated by leader
+// election. It sets nc.mode to nodeControllerModeCluster itself;
+// NewNodeControllerWithMode calls it for controller mode "cluster".
+func NewClusterNodeMonitor(nc *NodeController, kubeClient clientset.Interface, namespace, controllerID string) *ClusterNodeMonitor {
+	nc.mode = nodeControllerModeCluster
+	return &ClusterNodeMonitor{
+		nc:           nc,
+		namespace:    namespace,
+		controllerID: controllerID,
+		kubeClient:   kubeClient,
+	}
+}
+
+// Run blocks participating in leader election until stopCh closes or this
+// instance loses the lease; it does not rejoin the election afterwards.
+// Only while elected does it start the worker pool that drains nc's queue.
+// On losing the lease isClusterLeader flips back to false, so syncNode's
+// cluster-wide branch goes quiet; wait.Until starts no new worker runs, but
+// a worker already inside its loop keeps draining the queue.
+func (m *ClusterNodeMonitor) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logrus.Infof("Starting cluster node monitor leader election as %v", m.controllerID)
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		m.namespace,
+		clusterNodeMonitorLeaseName,
+		m.kubeClient.CoreV1(),
+		m.kubeClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      m.controllerID,
+			EventRecorder: m.nc.eventRecorder,
+		},
+	)
+	if err != nil {
+		logrus.Errorf("failed to create leader election lock for cluster node monitor: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: clusterNodeMonitorLeaseDuration,
+		RenewDeadline: clusterNodeMonitorRenewDeadline,
+		RetryPeriod:   clusterNodeMonitorRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				logrus.Infof("%v became the cluster node monitor leader", m.controllerID)
+				atomic.StoreInt32(&m.nc.isClusterLeader, 1)
+
+				for i := 0; i < workers; i++ {
+					go wait.Until(m.nc.worker, time.Second, leaderCtx.Done())
+				}
+				<-leaderCtx.Done()
+			},
+			OnStoppedLeading: func() {
+				atomic.StoreInt32(&m.nc.isClusterLeader, 0)
+				logrus.Infof("%v is no longer the cluster node monitor leader", m.controllerID)
+			},
+		},
+	})
+}
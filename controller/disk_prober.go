@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/longhorn/longhorn-manager/util"
+)
+
+// DiskProbeResult is one measurement pass over a disk: read/write latency
+// and an IO error rate, plus whatever predictive-failure signal is
+// available (SMART where DiskProvider can get it).
+type DiskProbeResult struct {
+	ReadLatencyMs  int64
+	WriteLatencyMs int64
+	IOErrorRate    float64
+	SmartFailed    bool
+}
+
+// DiskProber measures a disk's current read/write latency and error rate.
+// It's deliberately separate from DiskProvider (which reports capacity and
+// point-in-time SMART attributes): a prober actively exercises the disk on
+// a schedule, so swapping in an iostat-parsing, fio-style, or SMART-library
+// implementation doesn't change how capacity is read.
+type DiskProber interface {
+	Probe(path string) (*DiskProbeResult, error)
+}
+
+// ioErrorSample remembers the cumulative IOErrorCount and the wall-clock time
+// it was observed at, so a later Probe can turn DiskProvider's running
+// counter into a rate over the interval that actually elapsed instead of
+// treating the lifetime total as if it occurred in the last hour.
+type ioErrorSample struct {
+	count int64
+	at    time.Time
+}
+
+// LatencyDiskProber times a small read/write round trip against the disk
+// path and folds in the IO error counters and SMART failure flag the
+// configured DiskProvider already knows how to fetch.
+type LatencyDiskProber struct {
+	diskProvider DiskProvider
+
+	mu        sync.Mutex
+	lastIOErr map[string]ioErrorSample
+}
+
+func NewLatencyDiskProber(diskProvider DiskProvider) *LatencyDiskProber {
+	return &LatencyDiskProber{
+		diskProvider: diskProvider,
+		lastIOErr:    map[string]ioErrorSample{},
+	}
+}
+
+func (p *LatencyDiskProber) Probe(path string) (*DiskProbeResult, error) {
+	readLatency, writeLatency, err := util.MeasureDiskLatency(path)
+	if err != nil {
+		return nil, err
+	}
+
+	health, err := p.diskProvider.GetDiskHealth(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiskProbeResult{
+		ReadLatencyMs:  readLatency.Milliseconds(),
+		WriteLatencyMs: writeLatency.Milliseconds(),
+		IOErrorRate:    p.ioErrorRate(path, health.IOErrorCount),
+		SmartFailed:    health.PredictiveFailure,
+	}, nil
+}
+
+// ioErrorRate converts the cumulative count DiskProvider reports into errors
+// per second since the previous probe of this path. The first observation of
+// a path has nothing to diff against, so it reports zero rather than
+// assuming the whole count happened in some arbitrary window; it still
+// records the sample so the next probe can compute a real delta.
+func (p *LatencyDiskProber) ioErrorRate(path string, count int64) float64 {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prev, ok := p.lastIOErr[path]
+	p.lastIOErr[path] = ioErrorSample{count: count, at: now}
+	if !ok {
+		return 0
+	}
+
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 || count <= prev.count {
+		return 0
+	}
+	return float64(count-prev.count) / elapsed
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/longhorn/longhorn-manager/util"
+)
+
+// fakeDiskProvider returns the DiskHealth queued for the path's next call,
+// so a test can script a sequence of cumulative IOErrorCount readings
+// without touching the filesystem.
+type fakeDiskProvider struct {
+	health map[string][]*DiskHealth
+}
+
+func (f *fakeDiskProvider) GetDiskInfo(path string) (*util.DiskInfo, error) {
+	return &util.DiskInfo{}, nil
+}
+
+func (f *fakeDiskProvider) GetDiskHealth(path string) (*DiskHealth, error) {
+	queue := f.health[path]
+	health := queue[0]
+	if len(queue) > 1 {
+		f.health[path] = queue[1:]
+	}
+	return health, nil
+}
+
+func TestLatencyDiskProberIOErrorRate(t *testing.T) {
+	path := "/dev/fake"
+	provider := &fakeDiskProvider{
+		health: map[string][]*DiskHealth{
+			path: {
+				{IOErrorCount: 100},
+				{IOErrorCount: 160},
+			},
+		},
+	}
+	prober := NewLatencyDiskProber(provider)
+
+	first, err := prober.Probe(path)
+	if err != nil {
+		t.Fatalf("first probe failed: %v", err)
+	}
+	if first.IOErrorRate != 0 {
+		t.Fatalf("expected first probe (no prior sample) to report rate 0, got %v", first.IOErrorRate)
+	}
+
+	// Simulate the interval the real clock would have advanced between
+	// probes by back-dating the stored sample rather than sleeping.
+	prober.mu.Lock()
+	sample := prober.lastIOErr[path]
+	sample.at = sample.at.Add(-10 * time.Second)
+	prober.lastIOErr[path] = sample
+	prober.mu.Unlock()
+
+	second, err := prober.Probe(path)
+	if err != nil {
+		t.Fatalf("second probe failed: %v", err)
+	}
+	// The real clock also advances a little between the two probes, so the
+	// elapsed interval is just over 10s and the rate just under 6/s.
+	const want = float64(60) / 10
+	if second.IOErrorRate > want || second.IOErrorRate < want*0.99 {
+		t.Fatalf("expected rate %v errors/sec, got %v", want, second.IOErrorRate)
+	}
+}
+
+func TestLatencyDiskProberIOErrorRateCounterReset(t *testing.T) {
+	path := "/dev/fake-reset"
+	provider := &fakeDiskProvider{
+		health: map[string][]*DiskHealth{
+			path: {
+				{IOErrorCount: 100},
+				{IOErrorCount: 5},
+			},
+		},
+	}
+	prober := NewLatencyDiskProber(provider)
+
+	if _, err := prober.Probe(path); err != nil {
+		t.Fatalf("first probe failed: %v", err)
+	}
+
+	result, err := prober.Probe(path)
+	if err != nil {
+		t.Fatalf("second probe failed: %v", err)
+	}
+	if result.IOErrorRate != 0 {
+		t.Fatalf("expected rate 0 when the counter goes backwards (reset), got %v", result.IOErrorRate)
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/longhorn/longhorn-manager/util"
+)
+
+// DiskProvider abstracts how the node controller learns about a disk's
+// capacity and health, so a node can be backed by a plain local filesystem
+// (the original statfs-based behavior), a block device whose error counters
+// come from /proc/diskstats, a device queried directly via `smartctl
+// --json`, or a CSI driver's node capacity RPC.
+type DiskProvider interface {
+	// GetDiskInfo returns the same capacity/filesystem info
+	// util.GetDiskInfo always has: total/available bytes and the
+	// filesystem ID used to detect two disk entries pointing at the same
+	// underlying filesystem.
+	GetDiskInfo(path string) (*util.DiskInfo, error)
+
+	// GetDiskHealth returns predictive-failure signal for the disk backing
+	// path. Implementations that can't observe health (e.g. a CSI volume
+	// with no SMART passthrough) should return a DiskHealth with
+	// PredictiveFailure false rather than an error, since "unknown" and
+	// "healthy" are both better than flapping DiskConditionTypeHealthy.
+	GetDiskHealth(path string) (*DiskHealth, error)
+}
+
+// DiskHealth carries the additional per-disk signals DiskConditionTypeHealthy
+// is derived from.
+type DiskHealth struct {
+	IOErrorCount       int64
+	Temperature        int64
+	ReallocatedSectors int64
+	PredictiveFailure  bool
+}
+
+// StatfsDiskProvider is the original behavior: disk info comes from statfs
+// on the mounted path, and health is always reported healthy since statfs
+// alone can't see SMART data.
+type StatfsDiskProvider struct{}
+
+func (p *StatfsDiskProvider) GetDiskInfo(path string) (*util.DiskInfo, error) {
+	return util.GetDiskInfo(path)
+}
+
+func (p *StatfsDiskProvider) GetDiskHealth(path string) (*DiskHealth, error) {
+	return &DiskHealth{}, nil
+}
+
+// ProcDiskStatsDiskProvider layers IO error counters read from
+// /proc/diskstats on top of the statfs capacity numbers, for block devices
+// where the kernel already tracks read/write error counts.
+type ProcDiskStatsDiskProvider struct {
+	StatfsDiskProvider
+}
+
+func (p *ProcDiskStatsDiskProvider) GetDiskHealth(path string) (*DiskHealth, error) {
+	errorCount, err := util.GetDiskIOErrorCount(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskHealth{IOErrorCount: errorCount}, nil
+}
+
+// SmartctlDiskProvider shells out to `smartctl --json` for the underlying
+// block device, surfacing temperature, reallocated sector count, and the
+// drive's own predictive-failure assessment (SMART overall-health
+// self-assessment).
+type SmartctlDiskProvider struct {
+	StatfsDiskProvider
+}
+
+func (p *SmartctlDiskProvider) GetDiskHealth(path string) (*DiskHealth, error) {
+	report, err := util.GetSmartctlReport(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskHealth{
+		Temperature:        report.Temperature,
+		ReallocatedSectors: report.ReallocatedSectorCount,
+		PredictiveFailure:  !report.Passed,
+	}, nil
+}
+
+// CSIDiskProvider queries the CSI node plugin's NodeGetVolumeStats RPC for
+// capacity instead of statfs, for disks backed by a CSI volume rather than
+// a plain host path; it has no SMART visibility of its own so health always
+// reports healthy, same as StatfsDiskProvider.
+type CSIDiskProvider struct {
+	csiClient util.CSINodeClient
+}
+
+func NewCSIDiskProvider(csiClient util.CSINodeClient) *CSIDiskProvider {
+	return &CSIDiskProvider{csiClient: csiClient}
+}
+
+func (p *CSIDiskProvider) GetDiskInfo(path string) (*util.DiskInfo, error) {
+	return p.csiClient.GetVolumeStats(path)
+}
+
+func (p *CSIDiskProvider) GetDiskHealth(path string) (*DiskHealth, error) {
+	return &DiskHealth{}, nil
+}
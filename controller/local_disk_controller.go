@@ -0,0 +1,20 @@
+package controller
+
+// LocalDiskController is the per-node half of what used to be a single
+// NodeController: it reconciles only the disks and instance managers on
+// nc.controllerID's own node, leaving the cluster-wide Ready/Schedulable/
+// Zone/Region/cluster-health/eviction decisions to ClusterNodeMonitor. It
+// runs unconditionally, without leader election, since every node still
+// needs its own disk and instance-manager state reconciled regardless of
+// which manager pod currently holds the cluster leader lease.
+type LocalDiskController struct {
+	*NodeController
+}
+
+// NewLocalDiskController wraps nc (constructed via NewNodeController) so
+// syncNode only runs the disk/instance-manager half of the old combined
+// logic.
+func NewLocalDiskController(nc *NodeController) *LocalDiskController {
+	nc.mode = nodeControllerModeLocal
+	return &LocalDiskController{NodeController: nc}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -36,6 +38,12 @@ var (
 	ownerKindNode = longhorn.SchemeGroupVersion.WithKind("Node").String()
 )
 
+// diskResyncPeriod is how often syncDiskStatus re-probes every disk's
+// DiskProvider on top of the normal event-driven enqueues, so a disk that
+// degrades without any Kubernetes-visible event (predictive SMART failure,
+// climbing IO error counts) still gets noticed.
+const diskResyncPeriod = 30 * time.Second
+
 type NodeController struct {
 	// which namespace controller is running with
 	namespace    string
@@ -54,15 +62,60 @@ type NodeController struct {
 
 	queue workqueue.RateLimitingInterface
 
-	getDiskInfoHandler    GetDiskInfoHandler
+	diskProvider          DiskProvider
+	diskProber            DiskProber
 	topologyLabelsChecker TopologyLabelsChecker
 	getDiskConfig         GetDiskConfig
 	generateDiskConfig    GenerateDiskConfig
 
 	scheduler *scheduler.ReplicaScheduler
+
+	// zoneHealthMu guards zoneHealth, which tracks how long each failure
+	// domain has been back under the unhealthy-node threshold, so
+	// partial-disruption mode can require a stabilization window before
+	// resuming rebuilds rather than flapping on every synced node.
+	zoneHealthMu sync.Mutex
+	zoneHealth   map[string]*zoneHealthState
+
+	// mode controls which half of syncNode this instance runs. The zero
+	// value (nodeControllerModeStandalone) runs all of it, matching
+	// NodeController's original behavior for any existing caller of
+	// NewNodeController. NewClusterNodeMonitor/NewLocalDiskController set
+	// it to split the work, per chunk3-5.
+	mode nodeControllerMode
+	// isClusterLeader is only read/written in cluster mode, set by
+	// ClusterNodeMonitor's leader election callbacks. Every other mode
+	// leaves it at its zero value, which syncNode treats as "not gated by
+	// leadership" since it only consults this field in cluster mode.
+	isClusterLeader int32
+}
+
+// nodeControllerMode selects which half of syncNode a NodeController
+// instance runs. See ClusterNodeMonitor and LocalDiskController.
+type nodeControllerMode int
+
+const (
+	// nodeControllerModeStandalone runs both halves unconditionally. This
+	// is NodeController's original behavior, kept as the default so
+	// NewNodeController remains a drop-in constructor for anything that
+	// hasn't moved to the split controllers yet.
+	nodeControllerModeStandalone nodeControllerMode = iota
+	// nodeControllerModeCluster runs only the cluster-wide condition,
+	// zone-health, lifecycle, and evacuation-timer logic, and only while
+	// isClusterLeader is set.
+	nodeControllerModeCluster
+	// nodeControllerModeLocal runs only the current node's disk and
+	// instance-manager reconciliation, regardless of leadership.
+	nodeControllerModeLocal
+)
+
+// zoneHealthState is the in-memory partial-disruption state for one failure
+// domain (Status.Zone, or "" for nodes with no zone label).
+type zoneHealthState struct {
+	disrupted         bool
+	healthyObservedAt time.Time
 }
 
-type GetDiskInfoHandler func(string) (*util.DiskInfo, error)
 type TopologyLabelsChecker func(kubeClient clientset.Interface, vers string) (bool, error)
 
 type GetDiskConfig func(string) (*util.DiskConfig, error)
@@ -101,13 +154,16 @@ func NewNodeController(
 
 		queue: workqueue.NewNamedRateLimitingQueue(EnhancedDefaultControllerRateLimiter(), "longhorn-node"),
 
-		getDiskInfoHandler:    util.GetDiskInfo,
+		diskProvider:          &StatfsDiskProvider{},
 		topologyLabelsChecker: util.IsKubernetesVersionAtLeast,
 		getDiskConfig:         util.GetDiskConfig,
 		generateDiskConfig:    util.GenerateDiskConfig,
+
+		zoneHealth: map[string]*zoneHealthState{},
 	}
 
 	nc.scheduler = scheduler.NewReplicaScheduler(ds)
+	nc.diskProber = NewLatencyDiskProber(nc.diskProvider)
 
 	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -218,6 +274,49 @@ func NewNodeController(
 	return nc
 }
 
+// NodeControllerRunner is satisfied by NodeController, ClusterNodeMonitor,
+// and LocalDiskController alike, so NewNodeControllerWithMode can hand the
+// manager's main command one value to start regardless of which mode it
+// picked.
+type NodeControllerRunner interface {
+	Run(workers int, stopCh <-chan struct{})
+}
+
+// NewNodeControllerWithMode is the single entrypoint for picking between the
+// three NodeController personalities (chunk3-5): "" or "standalone" keeps
+// today's default of a plain NodeController running both halves of syncNode
+// unconditionally; "cluster" leader-elects a ClusterNodeMonitor; "local"
+// runs a LocalDiskController on every node with no election at all. The
+// manager's main command is expected to call this instead of
+// NewNodeController directly once it exposes a --node-controller-mode flag,
+// so mode selection lives in one place instead of being duplicated at every
+// call site.
+func NewNodeControllerWithMode(
+	mode string,
+	ds *datastore.DataStore,
+	scheme *runtime.Scheme,
+	nodeInformer lhinformers.NodeInformer,
+	settingInformer lhinformers.SettingInformer,
+	podInformer coreinformers.PodInformer,
+	replicaInformer lhinformers.ReplicaInformer,
+	kubeNodeInformer coreinformers.NodeInformer,
+	kubeClient clientset.Interface,
+	namespace, controllerID string,
+) (NodeControllerRunner, error) {
+	nc := NewNodeController(ds, scheme, nodeInformer, settingInformer, podInformer, replicaInformer, kubeNodeInformer, kubeClient, namespace, controllerID)
+
+	switch mode {
+	case "", "standalone":
+		return nc, nil
+	case "cluster":
+		return NewClusterNodeMonitor(nc, kubeClient, namespace, controllerID), nil
+	case "local":
+		return NewLocalDiskController(nc), nil
+	default:
+		return nil, fmt.Errorf("unknown node controller mode %q", mode)
+	}
+}
+
 func (nc *NodeController) filterSettings(s *longhorn.Setting) bool {
 	// filter that only StorageMinimalAvailablePercentage will impact disk status
 	if types.SettingName(s.Name) == types.SettingNameStorageMinimalAvailablePercentage {
@@ -264,9 +363,29 @@ func (nc *NodeController) Run(workers int, stopCh <-chan struct{}) {
 		go wait.Until(nc.worker, time.Second, stopCh)
 	}
 
+	// Disk health (SMART temperature, predictive failure) isn't something
+	// any informer will ever tell us changed, so on top of the normal
+	// event-driven enqueues, periodically resync this manager's own node
+	// (the only one whose disks syncNode reconciles here) and let
+	// syncDiskStatus re-probe each DiskProvider.
+	go wait.Until(nc.enqueueNodesForDiskResync, diskResyncPeriod, stopCh)
+
 	<-stopCh
 }
 
+func (nc *NodeController) enqueueNodesForDiskResync() {
+	nodes, err := nc.ds.ListNodes()
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list nodes for disk resync: %v", err))
+		return
+	}
+	for _, node := range nodes {
+		if node.Name == nc.controllerID {
+			nc.enqueueNode(node)
+		}
+	}
+}
+
 func (nc *NodeController) worker() {
 	for nc.processNextWorkItem() {
 	}
@@ -325,7 +444,21 @@ func (nc *NodeController) syncNode(key string) (err error) {
 		return err
 	}
 
+	if node.DeletionTimestamp != nil && nc.mode == nodeControllerModeLocal {
+		return nil
+	}
+
 	if node.DeletionTimestamp != nil {
+		done, err := nc.isEvacuationComplete(node)
+		if err != nil {
+			return err
+		}
+		if !done {
+			nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+				"Waiting for replica evacuation to complete before deleting node %v", node.Name)
+			nc.enqueueNode(node)
+			return nil
+		}
 		nc.eventRecorder.Eventf(node, v1.EventTypeWarning, EventReasonDelete, "Deleting node %v", node.Name)
 		return nc.ds.RemoveFinalizerForNode(node)
 	}
@@ -467,14 +600,43 @@ func (nc *NodeController) syncNode(key string) (err error) {
 		if err != nil {
 			return err
 		}
+		oldZone, oldRegion := node.Status.Zone, node.Status.Region
 		node.Status.Region, node.Status.Zone = types.GetRegionAndZone(kubeNode.Labels, isUsingTopologyLabels)
+		if oldZone != "" && (oldZone != node.Status.Zone || oldRegion != node.Status.Region) {
+			// Existing volumes were scheduled against the old failure
+			// domain, so their replica spread may no longer match
+			// ReplicaSoftAntiAffinity. Re-enqueuing the node lets the
+			// replica scheduler re-evaluate placement on its next pass
+			// instead of only noticing on the next unrelated change.
+			nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonUpdate,
+				"Node %v topology changed from zone=%v/region=%v to zone=%v/region=%v", node.Name, oldZone, oldRegion, node.Status.Zone, node.Status.Region)
+			nc.enqueueNode(node)
+		}
+
+	}
+
+	if nc.mode != nodeControllerModeLocal && nc.isClusterWork() {
+		if err := nc.syncClusterHealth(node); err != nil {
+			return err
+		}
 
+		if err := nc.syncNodeLifecycle(node); err != nil {
+			return err
+		}
+	}
+
+	if nc.mode == nodeControllerModeCluster {
+		return nil
 	}
 
 	if nc.controllerID != node.Name {
 		return nil
 	}
 
+	if err := nc.syncNodeEvacuation(node, kubeNode); err != nil {
+		return err
+	}
+
 	// sync disks status on current node
 	if err := nc.syncDiskStatus(node); err != nil {
 		return err
@@ -495,6 +657,379 @@ func (nc *NodeController) syncNode(key string) (err error) {
 	return nil
 }
 
+// syncClusterHealth tracks, per failure domain (Status.Zone), the fraction
+// of Longhorn nodes currently NotReady. If that ratio reaches
+// unhealthy-node-threshold-percentage (default 55%), the zone is put into
+// "partial disruption" mode: syncNodeLifecycle stops tainting nodes and
+// triggering rebuilds in that zone, since a genuine zone-wide outage makes
+// rebuilding every replica elsewhere both useless and a good way to
+// overload whatever capacity is left. The zone only resumes normal
+// operation once it's stayed below the threshold for
+// cluster-health-stabilization-window, so a flapping zone doesn't toggle
+// rebuilds on and off.
+func (nc *NodeController) syncClusterHealth(node *longhorn.Node) error {
+	thresholdPct, err := nc.ds.GetSettingAsInt(types.SettingNameUnhealthyNodeThresholdPercentage)
+	if err != nil {
+		return err
+	}
+	stabilizationWindow, err := nc.ds.GetSettingAsDuration(types.SettingNameClusterHealthStabilizationWindow)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := nc.ds.ListNodes()
+	if err != nil {
+		return err
+	}
+
+	total := map[string]int{}
+	unhealthy := map[string]int{}
+	for _, n := range nodes {
+		zone := n.Status.Zone
+		total[zone]++
+		readyCondition := types.GetCondition(n.Status.Conditions, types.NodeConditionTypeReady)
+		if readyCondition.Status != types.ConditionStatusTrue {
+			unhealthy[zone]++
+		}
+	}
+
+	zone := node.Status.Zone
+	ratio := float64(0)
+	if total[zone] > 0 {
+		ratio = float64(unhealthy[zone]) / float64(total[zone])
+	}
+	disrupted := ratio*100 >= float64(thresholdPct)
+
+	nc.zoneHealthMu.Lock()
+	state, ok := nc.zoneHealth[zone]
+	if !ok {
+		state = &zoneHealthState{}
+		nc.zoneHealth[zone] = state
+	}
+	if disrupted {
+		state.disrupted = true
+		state.healthyObservedAt = time.Time{}
+	} else if state.disrupted {
+		if state.healthyObservedAt.IsZero() {
+			state.healthyObservedAt = time.Now()
+		} else if time.Since(state.healthyObservedAt) >= stabilizationWindow {
+			state.disrupted = false
+		}
+	}
+	stillDisrupted := state.disrupted
+	nc.zoneHealthMu.Unlock()
+
+	if stillDisrupted {
+		node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+			types.NodeConditionTypeClusterHealth, types.ConditionStatusFalse,
+			string(types.NodeConditionReasonZonePartialDisruption),
+			fmt.Sprintf("Zone %v is %.0f%% unhealthy (threshold %v%%): halting rebuild scheduling and taint marking", zone, ratio*100, thresholdPct),
+			nc.eventRecorder, node, v1.EventTypeWarning)
+	} else {
+		node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+			types.NodeConditionTypeClusterHealth, types.ConditionStatusTrue,
+			"", "", nc.eventRecorder, node, v1.EventTypeNormal)
+	}
+
+	return nil
+}
+
+// isClusterWork reports whether this instance should run the cluster-wide
+// condition/lifecycle logic right now: always, outside cluster mode; only
+// while elected leader, in cluster mode.
+func (nc *NodeController) isClusterWork() bool {
+	if nc.mode != nodeControllerModeCluster {
+		return true
+	}
+	return atomic.LoadInt32(&nc.isClusterLeader) == 1
+}
+
+// isDiskProbeUnhealthy reports whether a DiskProbeResult crosses any of the
+// configured disk-health thresholds: read/write latency, IO error rate, or
+// (when disk-smart-fail-action asks for it) a SMART predictive-failure
+// flag. Any one crossed threshold is enough to call the disk unhealthy.
+func (nc *NodeController) isDiskProbeUnhealthy(probe *DiskProbeResult) (bool, error) {
+	maxLatencyMs, err := nc.ds.GetSettingAsInt(types.SettingNameDiskUnhealthyLatencyMS)
+	if err != nil {
+		return false, err
+	}
+	if probe.ReadLatencyMs > maxLatencyMs || probe.WriteLatencyMs > maxLatencyMs {
+		return true, nil
+	}
+
+	maxIOErrorRate, err := nc.ds.GetSettingAsInt(types.SettingNameDiskMaxIOErrorRate)
+	if err != nil {
+		return false, err
+	}
+	if probe.IOErrorRate > float64(maxIOErrorRate) {
+		return true, nil
+	}
+
+	if probe.SmartFailed {
+		action, err := nc.ds.GetSettingValueExisted(types.SettingNameDiskSmartFailAction)
+		if err != nil {
+			return false, err
+		}
+		if types.DiskSmartFailAction(action) == types.DiskSmartFailActionEvict {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isZoneDisrupted reports whether node's failure domain is currently in
+// partial-disruption mode, per the most recent syncClusterHealth call.
+func (nc *NodeController) isZoneDisrupted(node *longhorn.Node) bool {
+	nc.zoneHealthMu.Lock()
+	defer nc.zoneHealthMu.Unlock()
+	state, ok := nc.zoneHealth[node.Status.Zone]
+	return ok && state.disrupted
+}
+
+// nodeDrainAnnotationKey is the signal a kubectl-drain integration (or an
+// operator by hand) sets on the Kubernetes node, alongside cordoning it, to
+// ask Longhorn to evacuate replicas before the node is removed.
+const nodeDrainAnnotationKey = "node.longhorn.io/drain-requested"
+
+// isEvacuationRequested reports whether node should start evacuating its
+// local replicas: either Node.Spec.EvacuationRequested was set directly, or
+// the underlying Kubernetes node is cordoned and carries
+// nodeDrainAnnotationKey.
+func (nc *NodeController) isEvacuationRequested(node *longhorn.Node, kubeNode *v1.Node) bool {
+	if node.Spec.EvacuationRequested {
+		return true
+	}
+	if kubeNode == nil {
+		return false
+	}
+	_, annotated := kubeNode.Annotations[nodeDrainAnnotationKey]
+	return kubeNode.Spec.Unschedulable && annotated
+}
+
+// syncNodeEvacuation mirrors kubectl drain semantics for a Longhorn node:
+// every local replica is marked EvacuationRequested so the ReplicaScheduler
+// rebuilds it elsewhere, respecting each volume's
+// min-healthy-replica-percentage so a whole volume's replica set can't be
+// evacuated out from under it at once. Progress is reported through
+// NodeConditionTypeEvacuation so RemoveFinalizerForNode (via
+// isEvacuationComplete) knows when it's safe to let the node go.
+func (nc *NodeController) syncNodeEvacuation(node *longhorn.Node, kubeNode *v1.Node) error {
+	if !nc.isEvacuationRequested(node, kubeNode) {
+		node.Status.EvacuationRequestedAt = metav1.Time{}
+		node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+			types.NodeConditionTypeEvacuation, types.ConditionStatusUnknown,
+			"", "", nc.eventRecorder, node, v1.EventTypeNormal)
+		return nil
+	}
+
+	if node.Status.EvacuationRequestedAt.IsZero() {
+		node.Status.EvacuationRequestedAt = metav1.Now()
+		nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+			"Starting replica evacuation for node %v", node.Name)
+	}
+
+	timeout, err := nc.ds.GetSettingAsDuration(types.SettingNameNodeDrainTimeout)
+	if err != nil {
+		return err
+	}
+	minHealthyPct, err := nc.ds.GetSettingAsInt(types.SettingNameMinHealthyReplicaPercentage)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(node.Status.EvacuationRequestedAt.Time)
+	forceDrain := node.Spec.EvacuationForced || (elapsed >= timeout && timeout > 0)
+
+	replicasByDisk, err := nc.ds.ListReplicasByNode(node.Name)
+	if err != nil {
+		return err
+	}
+
+	pending, completed := 0, 0
+	for _, replicas := range replicasByDisk {
+		for _, r := range replicas {
+			if r.Spec.NodeID != node.Name {
+				completed++
+				continue
+			}
+
+			volumeReplicas, err := nc.ds.ListVolumeReplicas(r.Spec.VolumeName)
+			if err != nil {
+				return err
+			}
+			healthy := int64(0)
+			for _, vr := range volumeReplicas {
+				if vr.Spec.NodeID != node.Name && vr.Spec.FailedAt == "" {
+					healthy++
+				}
+			}
+			minHealthy := (int64(len(volumeReplicas)) * minHealthyPct) / 100
+			if !forceDrain && healthy < minHealthy {
+				pending++
+				continue
+			}
+
+			if !r.Spec.EvacuationRequested {
+				r.Spec.EvacuationRequested = true
+				if _, err := nc.ds.UpdateReplica(r); err != nil {
+					return err
+				}
+				nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+					"Requesting evacuation of replica %v (volume %v) from node %v", r.Name, r.Spec.VolumeName, node.Name)
+			}
+			pending++
+		}
+	}
+
+	if pending == 0 {
+		node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+			types.NodeConditionTypeEvacuation, types.ConditionStatusTrue,
+			string(types.NodeConditionReasonEvacuationComplete),
+			fmt.Sprintf("Evacuation of node %v complete: %v replicas moved", node.Name, completed),
+			nc.eventRecorder, node, v1.EventTypeNormal)
+		return nil
+	}
+
+	reason := types.NodeConditionReasonEvacuationInProgress
+	if forceDrain && elapsed >= timeout && timeout > 0 {
+		reason = types.NodeConditionReasonEvacuationTimedOut
+		nc.eventRecorder.Eventf(node, v1.EventTypeWarning, string(reason),
+			"Evacuation of node %v timed out after %v with %v replicas pending; force-draining", node.Name, elapsed, pending)
+	}
+
+	node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+		types.NodeConditionTypeEvacuation, types.ConditionStatusFalse,
+		string(reason),
+		fmt.Sprintf("Evacuating node %v: %v replicas pending, %v completed", node.Name, pending, completed),
+		nc.eventRecorder, node, v1.EventTypeNormal)
+
+	return nil
+}
+
+// isEvacuationComplete reports whether RemoveFinalizerForNode may proceed:
+// either evacuation was never requested, it's been force-drained, or
+// NodeConditionTypeEvacuation has already reported completion.
+func (nc *NodeController) isEvacuationComplete(node *longhorn.Node) (bool, error) {
+	kubeNode, err := nc.ds.GetKubernetesNode(node.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if !nc.isEvacuationRequested(node, kubeNode) || node.Spec.EvacuationForced {
+		return true, nil
+	}
+	cond := types.GetCondition(node.Status.Conditions, types.NodeConditionTypeEvacuation)
+	return cond.Status == types.ConditionStatusTrue, nil
+}
+
+// nodeUnavailableTaintKey/Effect mirror the upstream Kubernetes node
+// lifecycle controller's node.kubernetes.io/unreachable taint, but scoped to
+// Longhorn: it tells the volume/replica controllers to stop scheduling new
+// replicas onto this node, it doesn't evict the node's workload pods.
+const (
+	nodeUnavailableTaintKey    = "longhorn.io/node-unavailable"
+	nodeUnavailableTaintEffect = "NoExecute"
+)
+
+// syncNodeLifecycle implements the Unknown -> Unreachable grace period
+// modeled after kube-controller-manager's node lifecycle controller: a node
+// going NotReady is first marked Unknown for node-monitor-grace-period, then
+// promoted to Unreachable and tainted, and its replicas only become
+// eligible for rebuilding elsewhere after pod-eviction-timeout has also
+// elapsed. This
+// keeps a manager pod restart or a transient network blip from triggering a
+// rebuild storm across every replica the node was hosting.
+func (nc *NodeController) syncNodeLifecycle(node *longhorn.Node) error {
+	readyCondition := types.GetCondition(node.Status.Conditions, types.NodeConditionTypeReady)
+	if readyCondition.Status == types.ConditionStatusTrue {
+		if node.Status.UnreachableSince != nil {
+			node.Status.UnreachableSince = nil
+			node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+				types.NodeConditionTypeReachable, types.ConditionStatusTrue,
+				"", fmt.Sprintf("Node %v is reachable again", node.Name),
+				nc.eventRecorder, node, v1.EventTypeNormal)
+		}
+		return nil
+	}
+
+	monitorGracePeriod, err := nc.ds.GetSettingAsDuration(types.SettingNameNodeMonitorGracePeriod)
+	if err != nil {
+		return err
+	}
+	podEvictionTimeout, err := nc.ds.GetSettingAsDuration(types.SettingNamePodEvictionTimeout)
+	if err != nil {
+		return err
+	}
+
+	if node.Status.UnreachableSince == nil {
+		now := metav1.Now()
+		node.Status.UnreachableSince = &now
+		node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+			types.NodeConditionTypeReachable, types.ConditionStatusUnknown,
+			string(types.NodeConditionReasonNodeUnknown),
+			fmt.Sprintf("Node %v stopped reporting ready; waiting %v before marking unreachable", node.Name, monitorGracePeriod),
+			nc.eventRecorder, node, v1.EventTypeWarning)
+		return nil
+	}
+
+	unreadyFor := time.Since(node.Status.UnreachableSince.Time)
+	if unreadyFor < monitorGracePeriod {
+		return nil
+	}
+
+	node.Status.Conditions = types.SetConditionAndRecord(node.Status.Conditions,
+		types.NodeConditionTypeReachable, types.ConditionStatusFalse,
+		string(types.NodeConditionReasonNodeUnreachable),
+		fmt.Sprintf("Node %v has been unready for %v, marking unreachable", node.Name, unreadyFor),
+		nc.eventRecorder, node, v1.EventTypeWarning)
+
+	if nc.isZoneDisrupted(node) {
+		nc.eventRecorder.Eventf(node, v1.EventTypeWarning, types.NodeConditionReasonZonePartialDisruption,
+			"Skipping taint and rebuild for node %v: its zone is in partial disruption mode", node.Name)
+		return nil
+	}
+
+	if !types.HasTaint(node.Spec.Taints, nodeUnavailableTaintKey) {
+		node.Spec.Taints = types.AddTaint(node.Spec.Taints, nodeUnavailableTaintKey, nodeUnavailableTaintEffect)
+	}
+
+	if unreadyFor < monitorGracePeriod+podEvictionTimeout {
+		return nil
+	}
+
+	return nc.rebuildReplicasOnUnreachableNode(node, unreadyFor)
+}
+
+// rebuildReplicasOnUnreachableNode marks every replica still homed on node
+// EvacuationRequested, the same lever syncNodeEvacuation uses for a
+// deliberately-drained node, so the replica controller rebuilds each one
+// elsewhere instead of waiting indefinitely for a node that's stopped
+// reporting ready.
+func (nc *NodeController) rebuildReplicasOnUnreachableNode(node *longhorn.Node, unreadyFor time.Duration) error {
+	replicasByDisk, err := nc.ds.ListReplicasByNode(node.Name)
+	if err != nil {
+		return err
+	}
+
+	rebuilding := 0
+	for _, replicas := range replicasByDisk {
+		for _, r := range replicas {
+			if r.Spec.NodeID != node.Name || r.Spec.EvacuationRequested {
+				continue
+			}
+			r.Spec.EvacuationRequested = true
+			if _, err := nc.ds.UpdateReplica(r); err != nil {
+				return err
+			}
+			rebuilding++
+		}
+	}
+
+	nc.eventRecorder.Eventf(node, v1.EventTypeWarning, EventReasonRebuilding,
+		"Node %v has been unreachable for %v, triggering rebuild of %v replicas on other nodes", node.Name, unreadyFor, rebuilding)
+
+	return nil
+}
+
 func (nc *NodeController) enqueueNode(node *longhorn.Node) {
 	key, err := controller.KeyFunc(node)
 	if err != nil {
@@ -505,6 +1040,20 @@ func (nc *NodeController) enqueueNode(node *longhorn.Node) {
 	nc.queue.AddRateLimited(key)
 }
 
+// enqueueNodeAfter re-syncs node once duration has passed, independent of
+// the queue's usual rate limiting. reconcileInstanceManagerDrain uses this
+// to keep driving a drain to completion without waiting on whatever other
+// event next touches the node.
+func (nc *NodeController) enqueueNodeAfter(node *longhorn.Node, duration time.Duration) {
+	key, err := controller.KeyFunc(node)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", node, err))
+		return
+	}
+
+	nc.queue.AddAfter(key, duration)
+}
+
 func (nc *NodeController) enqueueSetting(setting *longhorn.Setting) {
 	nodeList, err := nc.ds.ListNodes()
 	if err != nil {
@@ -563,7 +1112,7 @@ type diskInfo struct {
 func (nc *NodeController) getDiskInfoMap(node *longhorn.Node) map[string]*diskInfo {
 	result := map[string]*diskInfo{}
 	for id, disk := range node.Spec.Disks {
-		info, err := nc.getDiskInfoHandler(disk.Path)
+		info, err := nc.diskProvider.GetDiskInfo(disk.Path)
 		result[id] = &diskInfo{
 			entry: info,
 			err:   err,
@@ -760,6 +1309,75 @@ func (nc *NodeController) syncDiskStatus(node *longhorn.Node) error {
 				"", fmt.Sprintf("Disk %v(%v) on node %v is schedulable", id, disk.Path, node.Name),
 				nc.eventRecorder, node, v1.EventTypeNormal)
 		}
+
+		// check disk health: a disk can still have capacity but be
+		// reporting predictive failure (SMART), so this is checked
+		// independently of Schedulable. ReplicaScheduler.IsSchedulableToDisk
+		// does not read this condition; it looks at capacity only.
+		health, err := nc.diskProvider.GetDiskHealth(disk.Path)
+		if err != nil {
+			diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+				types.DiskConditionTypeHealthy, types.ConditionStatusUnknown,
+				string(types.DiskConditionReasonNoDiskInfo),
+				fmt.Sprintf("failed to get health info for disk %v(%v) on node %v: %v", id, disk.Path, node.Name, err),
+				nc.eventRecorder, node, v1.EventTypeWarning)
+		} else {
+			diskStatus.IOErrorCount = health.IOErrorCount
+			diskStatus.SmartTemperature = health.Temperature
+			diskStatus.ReallocatedSectors = health.ReallocatedSectors
+			if health.PredictiveFailure {
+				diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+					types.DiskConditionTypeHealthy, types.ConditionStatusFalse,
+					string(types.DiskConditionReasonPredictiveFailure),
+					fmt.Sprintf("disk %v(%v) on node %v is reporting predictive failure: %v reallocated sectors, %vC",
+						id, disk.Path, node.Name, health.ReallocatedSectors, health.Temperature),
+					nc.eventRecorder, node, v1.EventTypeWarning)
+			} else {
+				diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+					types.DiskConditionTypeHealthy, types.ConditionStatusTrue,
+					"", fmt.Sprintf("disk %v(%v) on node %v is healthy", id, disk.Path, node.Name),
+					nc.eventRecorder, node, v1.EventTypeNormal)
+			}
+		}
+
+		// probe latency and IO error rate and auto-evict a disk that's
+		// crossed its configured thresholds, so the replica scheduler
+		// drains it before the disk hard-fails rather than after.
+		if probe, err := nc.diskProber.Probe(disk.Path); err != nil {
+			diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+				types.DiskConditionTypeLatency, types.ConditionStatusUnknown,
+				string(types.DiskConditionReasonNoDiskInfo),
+				fmt.Sprintf("failed to probe latency for disk %v(%v) on node %v: %v", id, disk.Path, node.Name, err),
+				nc.eventRecorder, node, v1.EventTypeWarning)
+		} else {
+			diskStatus.ReadLatencyMs = probe.ReadLatencyMs
+			diskStatus.WriteLatencyMs = probe.WriteLatencyMs
+			diskStatus.IOErrorRate = probe.IOErrorRate
+
+			unhealthy, err := nc.isDiskProbeUnhealthy(probe)
+			if err != nil {
+				return err
+			}
+			if unhealthy {
+				diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+					types.DiskConditionTypeLatency, types.ConditionStatusFalse,
+					string(types.DiskConditionReasonHighLatency),
+					fmt.Sprintf("disk %v(%v) on node %v crossed its latency/error-rate threshold: %vms read, %vms write, %v errors/s",
+						id, disk.Path, node.Name, probe.ReadLatencyMs, probe.WriteLatencyMs, probe.IOErrorRate),
+					nc.eventRecorder, node, v1.EventTypeWarning)
+				if !diskStatus.EvictionRequested {
+					diskStatus.EvictionRequested = true
+					nc.eventRecorder.Eventf(node, v1.EventTypeWarning, string(types.DiskConditionReasonHighLatency),
+						"requesting eviction of disk %v(%v) on node %v after crossing its health thresholds", id, disk.Path, node.Name)
+				}
+			} else {
+				diskStatus.Conditions = types.SetConditionAndRecord(diskStatus.Conditions,
+					types.DiskConditionTypeLatency, types.ConditionStatusTrue,
+					"", fmt.Sprintf("disk %v(%v) on node %v is within its latency/error-rate thresholds", id, disk.Path, node.Name),
+					nc.eventRecorder, node, v1.EventTypeNormal)
+			}
+		}
+
 		diskStatusMap[id] = diskStatus
 	}
 
@@ -801,12 +1419,32 @@ func (nc *NodeController) syncNodeStatus(pod *v1.Pod, node *longhorn.Node) error
 	return nil
 }
 
+// instanceManagerTarget is one (pool, type) tuple syncInstanceManagers
+// reconciles to exactly one running InstanceManager. poolName is "" for the
+// original global default, which every node remains eligible for so
+// clusters with no InstanceManagerPool CRs keep today's one-IM-per-type
+// behavior unchanged.
+type instanceManagerTarget struct {
+	poolName string
+	pool     *longhorn.InstanceManagerPool
+	image    string
+}
+
 func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 	defaultInstanceManagerImage, err := nc.ds.GetSettingValueExisted(types.SettingNameDefaultInstanceManagerImage)
 	if err != nil {
 		return err
 	}
 
+	pools, err := nc.ds.ListInstanceManagerPoolsForNode(node)
+	if err != nil {
+		return err
+	}
+	targets := []instanceManagerTarget{{poolName: "", image: defaultInstanceManagerImage}}
+	for name, pool := range pools {
+		targets = append(targets, instanceManagerTarget{poolName: name, pool: pool, image: pool.Spec.Image})
+	}
+
 	imTypes := []types.InstanceManagerType{types.InstanceManagerTypeEngine}
 
 	// Clean up all replica managers if there is no disk on the node
@@ -825,8 +1463,11 @@ func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 		imTypes = append(imTypes, types.InstanceManagerTypeReplica)
 	}
 
+	poolLabelKey := types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerPool)
+	drainProgress := map[string]types.InstanceManagerDrainStatus{}
+
 	for _, imType := range imTypes {
-		defaultInstanceManagerCreated := false
+		targetCreated := map[string]bool{}
 		imMap, err := nc.ds.ListInstanceManagersByNode(node.Name, imType)
 		if err != nil {
 			return err
@@ -836,20 +1477,39 @@ func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 				return fmt.Errorf("BUG: Instance manager %v NodeID %v is not consistent with the label %v=%v",
 					im.Name, im.Spec.NodeID, types.GetLonghornLabelKey(types.LonghornLabelNode), im.Labels[types.GetLonghornLabelKey(types.LonghornLabelNode)])
 			}
+			imPoolName := im.Labels[poolLabelKey]
+
+			var matchedTarget *instanceManagerTarget
+			for i := range targets {
+				if targets[i].poolName == imPoolName {
+					matchedTarget = &targets[i]
+					break
+				}
+			}
+
 			cleanupRequired := true
-			if im.Spec.Image == defaultInstanceManagerImage {
-				// Create default instance manager if needed.
-				defaultInstanceManagerCreated = true
+			if matchedTarget != nil && im.Spec.Image == matchedTarget.image {
+				// Create the target's instance manager if needed.
+				targetCreated[imPoolName] = true
 				cleanupRequired = false
 			} else {
-				// Clean up old instance managers if there is no running instance.
-				if im.Status.CurrentState == types.InstanceManagerStateRunning && im.DeletionTimestamp == nil {
-					for _, instance := range im.Status.Instances {
-						if instance.Status.State == types.InstanceStateRunning || instance.Status.State == types.InstanceStateStarting {
-							cleanupRequired = false
-							break
-						}
+				// im is superseded (a different target now owns this pool's
+				// image, or the pool is gone). Drive it through the drain
+				// state machine rather than deleting it outright, so a busy
+				// node doesn't stall forever waiting for every instance to
+				// stop on its own.
+				drained, err := nc.reconcileInstanceManagerDrain(node, im)
+				if err != nil {
+					return err
+				}
+				cleanupRequired = drained
+				if !drained {
+					drainProgress[im.Name] = types.InstanceManagerDrainStatus{
+						Phase:              im.Status.DrainPhase,
+						InstancesRemaining: im.Status.DrainInstancesRemaining,
+						InstancesTotal:     im.Status.DrainInstancesTotal,
 					}
+					nc.enqueueNodeAfter(node, instanceManagerDrainRequeuePeriod)
 				}
 			}
 			if cleanupRequired {
@@ -859,34 +1519,197 @@ func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 				}
 			}
 		}
-		if !defaultInstanceManagerCreated {
+
+		for _, target := range targets {
+			if targetCreated[target.poolName] {
+				continue
+			}
 			imName, err := types.GetInstanceManagerName(imType)
 			if err != nil {
 				return err
 			}
-			logrus.Debugf("Prepare to create default instance manager %v, node: %v, default instance manager image: %v, type: %v",
-				imName, node.Name, defaultInstanceManagerImage, imType)
-			if _, err := nc.createInstanceManager(node, imName, defaultInstanceManagerImage, imType); err != nil {
+			logrus.Debugf("Prepare to create instance manager %v, node: %v, image: %v, type: %v, pool: %v",
+				imName, node.Name, target.image, imType, target.poolName)
+			if _, err := nc.createInstanceManager(node, imName, target.image, imType, target.pool); err != nil {
 				return err
 			}
 		}
 	}
+
+	node.Status.InstanceManagerDrainProgress = drainProgress
+
 	return nil
 }
 
-func (nc *NodeController) createInstanceManager(node *longhorn.Node, imName, image string, imType types.InstanceManagerType) (*longhorn.InstanceManager, error) {
+// createInstanceManager creates the InstanceManager for imType on node,
+// running pool.Spec.Image with pool's node selector already having matched
+// (that's why this node reconciles it at all), requests/limits, tolerations,
+// and workload-class label. pool is nil for the global default image, which
+// gets none of those per-pool settings.
+func (nc *NodeController) createInstanceManager(node *longhorn.Node, imName, image string, imType types.InstanceManagerType, pool *longhorn.InstanceManagerPool) (*longhorn.InstanceManager, error) {
+	instanceManagerLabels := types.GetInstanceManagerLabels(node.Name, image, imType)
+
+	spec := types.InstanceManagerSpec{
+		Image:  image,
+		NodeID: node.Name,
+		Type:   imType,
+	}
+
+	if pool != nil {
+		instanceManagerLabels[types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerPool)] = pool.Name
+		if pool.Spec.WorkloadClass != "" {
+			instanceManagerLabels[types.GetLonghornLabelKey(types.LonghornLabelWorkloadClass)] = pool.Spec.WorkloadClass
+		}
+		spec.Tolerations = pool.Spec.Tolerations
+		spec.ResourceRequests = pool.Spec.ResourceRequests
+		spec.ResourceLimits = pool.Spec.ResourceLimits
+	}
+
 	instanceManager := &longhorn.InstanceManager{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:          types.GetInstanceManagerLabels(node.Name, image, imType),
+			Labels:          instanceManagerLabels,
 			Name:            imName,
 			OwnerReferences: datastore.GetOwnerReferencesForNode(node),
 		},
-		Spec: types.InstanceManagerSpec{
-			Image:  image,
-			NodeID: node.Name,
-			Type:   imType,
-		},
+		Spec: spec,
 	}
 
 	return nc.ds.CreateInstanceManager(instanceManager)
 }
+
+// instanceManagerDrainRequeuePeriod is how soon syncInstanceManagers revisits
+// a draining instance manager, so a rolling image upgrade keeps making
+// progress without waiting for some unrelated node event to re-trigger sync.
+const instanceManagerDrainRequeuePeriod = 15 * time.Second
+
+// reconcileInstanceManagerDrain advances im's drain state machine by one
+// step and reports whether im is now empty and safe to delete. A superseded
+// instance manager (wrong image, or its pool/target no longer exists) is
+// cordoned first so no new instance is scheduled to it, then has its
+// instances evicted a few at a time -- honoring
+// SettingNameInstanceManagerMaxUnavailable. Replica instances are evicted via
+// the same Spec.EvacuationRequested lever syncNodeEvacuation uses for node
+// draining, so the replica controller rebuilds each one elsewhere. Engine
+// instances have no equivalent evacuation flag, so they're unpinned instead
+// (clearing Spec.InstanceManagerName/InstanceManagerPool) so the engine
+// controller's normal IM-resolution picks a new, non-cordoned IM on its next
+// reconcile. Progress is recorded on im.Status, and syncInstanceManagers
+// copies it into the Node CR's Status.InstanceManagerDrainProgress.
+func (nc *NodeController) reconcileInstanceManagerDrain(node *longhorn.Node, im *longhorn.InstanceManager) (bool, error) {
+	if !im.Spec.Cordoned {
+		im.Spec.Cordoned = true
+		updated, err := nc.ds.UpdateInstanceManager(im)
+		if err != nil {
+			return false, err
+		}
+		*im = *updated
+		nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+			"cordoned instance manager %v on node %v ahead of draining it", im.Name, node.Name)
+	}
+
+	remaining := []string{}
+	for instanceName, instance := range im.Status.Instances {
+		if instance.Status.State == types.InstanceStateRunning || instance.Status.State == types.InstanceStateStarting {
+			remaining = append(remaining, instanceName)
+		}
+	}
+
+	im.Status.DrainInstancesRemaining = len(remaining)
+	if im.Status.DrainPhase != types.InstanceManagerDrainPhaseDraining {
+		im.Status.DrainPhase = types.InstanceManagerDrainPhaseDraining
+		im.Status.DrainInstancesTotal = len(remaining)
+	}
+
+	if len(remaining) == 0 {
+		im.Status.DrainPhase = types.InstanceManagerDrainPhaseComplete
+		if _, err := nc.ds.UpdateInstanceManagerStatus(im); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	maxUnavailable, err := nc.ds.GetSettingAsInt(types.SettingNameInstanceManagerMaxUnavailable)
+	if err != nil {
+		return false, err
+	}
+
+	// evicting tracks the eviction budget as instances already set in motion
+	// by a previous pass, not just ones this call starts: a replica with
+	// EvacuationRequested or an engine already unpinned is still draining,
+	// just not yet off of "remaining" (the engine/replica controllers haven't
+	// finished moving it). Counting only this-call evictions here let every
+	// instanceManagerDrainRequeuePeriod pass pile maxUnavailable more on top
+	// of whatever was already in flight, so count in-flight first and only
+	// spend what's left of the budget on new evictions.
+	evicting := int64(0)
+	for _, instanceName := range remaining {
+		if replica, err := nc.ds.GetReplica(instanceName); err == nil {
+			if replica.Spec.EvacuationRequested {
+				evicting++
+			}
+			continue
+		} else if !datastore.ErrorIsNotFound(err) {
+			return false, err
+		}
+
+		engine, err := nc.ds.GetEngine(instanceName)
+		if err != nil {
+			if datastore.ErrorIsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if engine.Spec.InstanceManagerName == "" && engine.Spec.InstanceManagerPool == "" {
+			evicting++
+		}
+	}
+
+	for _, instanceName := range remaining {
+		if evicting >= maxUnavailable {
+			break
+		}
+		if replica, err := nc.ds.GetReplica(instanceName); err == nil {
+			if replica.Spec.EvacuationRequested {
+				continue
+			}
+			replica.Spec.EvacuationRequested = true
+			if _, err := nc.ds.UpdateReplica(replica); err != nil {
+				return false, err
+			}
+			evicting++
+			continue
+		} else if !datastore.ErrorIsNotFound(err) {
+			return false, err
+		}
+
+		engine, err := nc.ds.GetEngine(instanceName)
+		if err != nil {
+			if datastore.ErrorIsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if engine.Spec.InstanceManagerName == "" && engine.Spec.InstanceManagerPool == "" {
+			// Already unpinned by a previous pass; nothing more this
+			// controller can do until the engine controller picks a new IM.
+			continue
+		}
+		engine.Spec.InstanceManagerName = ""
+		engine.Spec.InstanceManagerPool = ""
+		if _, err := nc.ds.UpdateEngine(engine); err != nil {
+			return false, err
+		}
+		nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+			"Unpinned engine %v from cordoned instance manager %v so it fails over to a new one", engine.Name, im.Name)
+		evicting++
+	}
+
+	nc.eventRecorder.Eventf(node, v1.EventTypeNormal, EventReasonEvacuating,
+		"draining instance manager %v on node %v: %v/%v instances remaining",
+		im.Name, node.Name, im.Status.DrainInstancesRemaining, im.Status.DrainInstancesTotal)
+
+	if _, err := nc.ds.UpdateInstanceManagerStatus(im); err != nil {
+		return false, err
+	}
+	return false, nil
+}
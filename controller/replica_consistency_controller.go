@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/engineapi"
+)
+
+const (
+	replicaConsistencyCheckPeriod = 30 * time.Second
+
+	// replicaDivergenceGracePeriod is how long a replica must be
+	// continuously reported Diverged before reconcileEngine actually removes
+	// it from the live engine, so a replica that's merely a write or two
+	// behind mid-operation isn't yanked out and rebuilt over a transient
+	// blip.
+	replicaDivergenceGracePeriod = 2 * time.Minute
+)
+
+// ReplicaConsistencyController periodically asks every running engine to
+// verify its replica set's revision counters, so a replica that silently
+// diverged (e.g. it missed writes during a network partition) gets flagged
+// before it's trusted for a rebuild source or promoted to primary.
+//
+// Unlike NodeController this isn't driven off informer events: divergence
+// doesn't show up as a CR change, only as a difference the engine itself
+// has to be asked about, so it runs on a plain ticker instead of a
+// workqueue.
+type ReplicaConsistencyController struct {
+	namespace    string
+	controllerID string
+
+	ds *datastore.DataStore
+
+	eventRecorder record.EventRecorder
+	engines       *engineapi.EngineCollection
+
+	// divergedSince remembers, per replica URL, the first reconcileEngine
+	// call that saw it Diverged. reconcileAll runs on a single ticker
+	// goroutine (see Run), so this needs no locking.
+	divergedSince map[string]time.Time
+}
+
+func NewReplicaConsistencyController(
+	ds *datastore.DataStore,
+	engines *engineapi.EngineCollection,
+	kubeClient clientset.Interface,
+	eventRecorder record.EventRecorder,
+	namespace, controllerID string) *ReplicaConsistencyController {
+
+	return &ReplicaConsistencyController{
+		namespace:    namespace,
+		controllerID: controllerID,
+
+		ds: ds,
+
+		eventRecorder: eventRecorder,
+		engines:       engines,
+
+		divergedSince: map[string]time.Time{},
+	}
+}
+
+// Run blocks, periodically reconciling every engine's replica consistency
+// until stopCh is closed.
+func (rcc *ReplicaConsistencyController) Run(stopCh <-chan struct{}) {
+	defer logrus.Infof("Shutting down replica consistency controller")
+
+	wait.Until(rcc.reconcileAll, replicaConsistencyCheckPeriod, stopCh)
+}
+
+func (rcc *ReplicaConsistencyController) reconcileAll() {
+	engines, err := rcc.ds.ListEnginesRO()
+	if err != nil {
+		logrus.Errorf("failed to list engines for replica consistency check: %v", err)
+		return
+	}
+
+	for _, e := range engines {
+		if e.Spec.NodeID != rcc.controllerID {
+			continue
+		}
+		if err := rcc.reconcileEngine(e.Name, e.Spec.VolumeName, e.Status.Endpoint); err != nil {
+			logrus.Errorf("failed to verify replica consistency for engine %v: %v", e.Name, err)
+		}
+	}
+}
+
+func (rcc *ReplicaConsistencyController) reconcileEngine(engineName, volumeName, controllerURL string) error {
+	client, err := rcc.engines.NewEngineClient(&engineapi.EngineClientRequest{
+		VolumeName:    volumeName,
+		ControllerURL: controllerURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	statuses, err := client.VerifyReplicaConsistency()
+	if err != nil {
+		return err
+	}
+
+	volume, err := rcc.ds.GetVolume(volumeName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for url, status := range statuses {
+		if !status.Diverged {
+			delete(rcc.divergedSince, url)
+			continue
+		}
+
+		since, seenBefore := rcc.divergedSince[url]
+		if !seenBefore {
+			rcc.divergedSince[url] = now
+			rcc.eventRecorder.Eventf(volume, v1.EventTypeWarning, EventReasonRebuilding,
+				"Replica at %v diverged for volume %v: counter %v is behind the rest of the set", url, volumeName, status.Counter)
+			continue
+		}
+		if now.Sub(since) < replicaDivergenceGracePeriod {
+			continue
+		}
+
+		if err := client.ReplicaRemove(url); err != nil {
+			return errors.Wrapf(err, "failed to remove diverged replica %v from engine %v", url, engineName)
+		}
+		delete(rcc.divergedSince, url)
+		rcc.eventRecorder.Eventf(volume, v1.EventTypeWarning, EventReasonRebuilding,
+			"Removed diverged replica at %v from engine %v for volume %v after it stayed behind the rest of the set for %v; it will be rebuilt",
+			url, engineName, volumeName, replicaDivergenceGracePeriod)
+	}
+
+	for url := range rcc.divergedSince {
+		if _, ok := statuses[url]; !ok {
+			delete(rcc.divergedSince, url)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package datastore
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+)
+
+const (
+	indexerByVolume      = "by-volume"
+	indexerByNode        = "by-node"
+	indexerByEngineImage = "by-engineImage"
+	indexerByStandby     = "by-standby"
+)
+
+// replicaIndexers and engineIndexers are registered on the shared Replica and
+// Engine informers at startup so that cross-reference lookups that used to
+// walk every object (ListVolumeEngines, ListVolumeReplicas) can instead do an
+// indexer.ByIndex lookup. Keep these in sync with checkReplica/checkEngine:
+// any field the index keys off of must stay populated whenever an object
+// passes validation.
+var replicaIndexers = cache.Indexers{
+	indexerByVolume: func(obj interface{}) ([]string, error) {
+		r, ok := obj.(*longhorn.Replica)
+		if !ok {
+			return nil, fmt.Errorf("expected *longhorn.Replica, got %T", obj)
+		}
+		return []string{r.Spec.VolumeName}, nil
+	},
+	indexerByNode: func(obj interface{}) ([]string, error) {
+		r, ok := obj.(*longhorn.Replica)
+		if !ok {
+			return nil, fmt.Errorf("expected *longhorn.Replica, got %T", obj)
+		}
+		if r.Spec.NodeID == "" {
+			return []string{}, nil
+		}
+		return []string{r.Spec.NodeID}, nil
+	},
+}
+
+var engineIndexers = cache.Indexers{
+	indexerByVolume: func(obj interface{}) ([]string, error) {
+		e, ok := obj.(*longhorn.Engine)
+		if !ok {
+			return nil, fmt.Errorf("expected *longhorn.Engine, got %T", obj)
+		}
+		return []string{e.Spec.VolumeName}, nil
+	},
+	indexerByEngineImage: func(obj interface{}) ([]string, error) {
+		e, ok := obj.(*longhorn.Engine)
+		if !ok {
+			return nil, fmt.Errorf("expected *longhorn.Engine, got %T", obj)
+		}
+		return []string{e.Spec.EngineImage}, nil
+	},
+}
+
+var volumeIndexers = cache.Indexers{
+	indexerByStandby: func(obj interface{}) ([]string, error) {
+		v, ok := obj.(*longhorn.Volume)
+		if !ok {
+			return nil, fmt.Errorf("expected *longhorn.Volume, got %T", obj)
+		}
+		if !v.Spec.Standby {
+			return []string{}, nil
+		}
+		return []string{"true"}, nil
+	},
+}
+
+// RegisterIndexers adds the custom indexers above to the shared informers
+// backing this DataStore. It must be called once, before the informer
+// factory is started, since client-go panics if indexers are added to an
+// already-running informer.
+func RegisterIndexers(rInformerIndexer cache.Indexer, eInformerIndexer cache.Indexer, vInformerIndexer cache.Indexer) error {
+	if err := rInformerIndexer.AddIndexers(replicaIndexers); err != nil {
+		return err
+	}
+	if err := eInformerIndexer.AddIndexers(engineIndexers); err != nil {
+		return err
+	}
+	if err := vInformerIndexer.AddIndexers(volumeIndexers); err != nil {
+		return err
+	}
+	return nil
+}
+
+// replicasFromIndex converts the interface{} slice returned by
+// cache.Indexer.ByIndex into the map[string]*longhorn.Replica shape the rest
+// of DataStore already returns, so callers of ListVolumeReplicas don't need
+// to change.
+func replicasFromIndex(objs []interface{}) (map[string]*longhorn.Replica, error) {
+	itemMap := make(map[string]*longhorn.Replica, len(objs))
+	for _, obj := range objs {
+		r, ok := obj.(*longhorn.Replica)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: indexer returned wrong type for replica: %T", obj)
+		}
+		itemMap[r.Name] = r.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+func enginesFromIndex(objs []interface{}) (map[string]*longhorn.Engine, error) {
+	itemMap := make(map[string]*longhorn.Engine, len(objs))
+	for _, obj := range objs {
+		e, ok := obj.(*longhorn.Engine)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: indexer returned wrong type for engine: %T", obj)
+		}
+		itemMap[e.Name] = e.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+func volumesFromIndex(objs []interface{}) (map[string]*longhorn.Volume, error) {
+	itemMap := make(map[string]*longhorn.Volume, len(objs))
+	for _, obj := range objs {
+		v, ok := obj.(*longhorn.Volume)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: indexer returned wrong type for volume: %T", obj)
+		}
+		itemMap[v.Name] = v
+	}
+	return itemMap, nil
+}
+
+// ListVolumeEnginesByIndex is an O(1) replacement for ListVolumeEngines that
+// reads from the by-volume index instead of listing and filtering every
+// Engine. Falls back to the label-selector list only when this DataStore
+// has no engine indexer; it does not check whether the informer has synced.
+func (s *DataStore) ListVolumeEnginesByIndex(volumeName string) (map[string]*longhorn.Engine, error) {
+	if s.eIndexer == nil {
+		return s.ListVolumeEngines(volumeName)
+	}
+	objs, err := s.eIndexer.ByIndex(indexerByVolume, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	return enginesFromIndex(objs)
+}
+
+// ListVolumeReplicasByIndex is an O(1) replacement for ListVolumeReplicas.
+func (s *DataStore) ListVolumeReplicasByIndex(volumeName string) (map[string]*longhorn.Replica, error) {
+	if s.rIndexer == nil {
+		return s.ListVolumeReplicas(volumeName)
+	}
+	objs, err := s.rIndexer.ByIndex(indexerByVolume, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[string]*longhorn.Replica, len(objs))
+	for _, obj := range objs {
+		r, ok := obj.(*longhorn.Replica)
+		if !ok {
+			return nil, fmt.Errorf("BUG: datastore: indexer returned wrong type for replica: %T", obj)
+		}
+		fixed, err := s.fixupReplica(r.DeepCopy())
+		if err != nil {
+			return nil, err
+		}
+		itemMap[r.Name] = fixed
+	}
+	return itemMap, nil
+}
+
+// ListStandbyVolumesROByIndex is an O(1) replacement for ListStandbyVolumesRO.
+func (s *DataStore) ListStandbyVolumesROByIndex() (map[string]*longhorn.Volume, error) {
+	if s.vIndexer == nil {
+		return s.ListStandbyVolumesRO()
+	}
+	objs, err := s.vIndexer.ByIndex(indexerByStandby, "true")
+	if err != nil {
+		return nil, err
+	}
+	return volumesFromIndex(objs)
+}
@@ -0,0 +1,83 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+)
+
+// benchReplicaCount and benchVolumeCount size the synthetic fixture the two
+// benchmarks below share: 10k replicas spread evenly across 1k volumes (10
+// replicas per volume), matching the scale ListVolumeReplicasByIndex was
+// introduced to handle without walking the whole Replica set on every call.
+const (
+	benchReplicaCount = 10000
+	benchVolumeCount  = 1000
+)
+
+func newBenchReplicaFixture() ([]*longhorn.Replica, string) {
+	replicas := make([]*longhorn.Replica, 0, benchReplicaCount)
+	targetVolume := "vol-0"
+	for i := 0; i < benchReplicaCount; i++ {
+		volumeName := fmt.Sprintf("vol-%d", i%benchVolumeCount)
+		replicas = append(replicas, &longhorn.Replica{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("replica-%d", i),
+				Namespace: "longhorn-system",
+			},
+			Spec: longhorn.ReplicaSpec{
+				VolumeName: volumeName,
+			},
+		})
+	}
+	return replicas, targetVolume
+}
+
+// BenchmarkListVolumeReplicasByScan mirrors what ListVolumeReplicas did
+// before the by-volume indexer existed: scan every Replica and keep the ones
+// whose VolumeName label/field matches.
+func BenchmarkListVolumeReplicasByScan(b *testing.B) {
+	replicas, targetVolume := newBenchReplicaFixture()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matched := make(map[string]*longhorn.Replica)
+		for _, r := range replicas {
+			if r.Spec.VolumeName == targetVolume {
+				matched[r.Name] = r
+			}
+		}
+		if len(matched) != benchReplicaCount/benchVolumeCount {
+			b.Fatalf("expected %d replicas for %v, got %d", benchReplicaCount/benchVolumeCount, targetVolume, len(matched))
+		}
+	}
+}
+
+// BenchmarkListVolumeReplicasByIndex exercises the same lookup through a
+// cache.Indexer built from replicaIndexers, the same index
+// ListVolumeReplicasByIndex reads from in DataStore.
+func BenchmarkListVolumeReplicasByIndex(b *testing.B) {
+	replicas, targetVolume := newBenchReplicaFixture()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, replicaIndexers)
+	for _, r := range replicas {
+		if err := indexer.Add(r); err != nil {
+			b.Fatalf("failed to seed indexer: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		objs, err := indexer.ByIndex(indexerByVolume, targetVolume)
+		if err != nil {
+			b.Fatalf("ByIndex failed: %v", err)
+		}
+		if len(objs) != benchReplicaCount/benchVolumeCount {
+			b.Fatalf("expected %d replicas for %v, got %d", benchReplicaCount/benchVolumeCount, targetVolume, len(objs))
+		}
+	}
+}
@@ -8,12 +8,14 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
@@ -29,6 +31,15 @@ const (
 var (
 	longhornFinalizerKey = longhorn.SchemeGroupVersion.Group
 
+	// settingAppliedDefaultAnnotation records, on a Setting CR, which
+	// types.SettingDefinitions default value was last applied to it. It's
+	// set whenever reconcileSettingSchema (or InitSettings, on first
+	// creation) writes the schema default into Value, so a later version
+	// bump that changes the declared default can tell "this is still sitting
+	// at whatever the default used to be, safe to upgrade" apart from "a
+	// user explicitly set this," which must never be overwritten.
+	settingAppliedDefaultAnnotation = longhorn.SchemeGroupVersion.Group + "/applied-default"
+
 	VerificationRetryInterval = 100 * time.Millisecond
 	VerificationRetryCounts   = 20
 )
@@ -43,7 +54,8 @@ func (s *DataStore) InitSettings() error {
 			if ErrorIsNotFound(err) {
 				setting := &longhorn.Setting{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: string(sName),
+						Name:        string(sName),
+						Annotations: map[string]string{settingAppliedDefaultAnnotation: definition.Default},
 					},
 					Setting: types.Setting{
 						Value: definition.Default,
@@ -57,6 +69,52 @@ func (s *DataStore) InitSettings() error {
 			}
 		}
 	}
+	return s.reconcileSettingSchema()
+}
+
+// reconcileSettingSchema removes Setting CRs that no longer appear in
+// types.SettingDefinitions, so a setting dropped by an upgrade doesn't sit
+// around as an orphaned CR forever, and upgrades the value of any setting
+// still sitting at the default that was applied when it was last written,
+// if types.SettingDefinitions now declares a different default for it.
+//
+// Settings created before settingAppliedDefaultAnnotation existed have no
+// recorded applied-default to compare against, so they're left untouched
+// here rather than guessed at -- there's no way to tell whether such a
+// setting's current value was customized by a user or just happens to match
+// an old default.
+func (s *DataStore) reconcileSettingSchema() error {
+	existing, err := s.sLister.Settings(s.namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, setting := range existing {
+		sName := types.SettingName(setting.Name)
+		definition, ok := types.SettingDefinitions[sName]
+		if !ok {
+			logrus.Warnf("Removing obsolete setting %v no longer declared in SettingDefinitions", setting.Name)
+			if err := s.lhClient.LonghornV1beta1().Settings(s.namespace).Delete(setting.Name, &metav1.DeleteOptions{}); err != nil && !ErrorIsNotFound(err) {
+				return err
+			}
+			continue
+		}
+
+		appliedDefault, ok := setting.Annotations[settingAppliedDefaultAnnotation]
+		if !ok || appliedDefault == definition.Default || setting.Value != appliedDefault {
+			continue
+		}
+
+		logrus.Infof("Upgrading setting %v default from %v to %v", setting.Name, appliedDefault, definition.Default)
+		updated := setting.DeepCopy()
+		updated.Value = definition.Default
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[settingAppliedDefaultAnnotation] = definition.Default
+		if _, err := s.UpdateSetting(updated); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -87,18 +145,6 @@ func (s *DataStore) ValidateSetting(name, value string) (err error) {
 	}
 
 	switch sName {
-	case types.SettingNameBackupTarget:
-		vs, err := s.ListStandbyVolumesRO()
-		if err != nil {
-			return errors.Wrapf(err, "failed to list standby volume when modifying BackupTarget")
-		}
-		if len(vs) != 0 {
-			standbyVolumeNames := make([]string, len(vs))
-			for k := range vs {
-				standbyVolumeNames = append(standbyVolumeNames, k)
-			}
-			return fmt.Errorf("cannot modify BackupTarget since there are existing standby volumes: %v", standbyVolumeNames)
-		}
 	case types.SettingNameTaintToleration:
 		list, err := s.ListVolumesRO()
 		if err != nil {
@@ -125,6 +171,12 @@ func (s *DataStore) ValidateSetting(name, value string) (err error) {
 			}
 		}
 	}
+
+	if validator, ok := settingValidators[sName]; ok {
+		if err := validator(s, value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -218,6 +270,21 @@ func (s *DataStore) GetCredentialFromSecret(secretName string) (map[string]strin
 	return credentialSecret, nil
 }
 
+// GetCredentialFromBackupTarget generalizes GetCredentialFromSecret to look
+// up the secret referenced by a specific BackupTarget object rather than the
+// global SettingNameBackupTarget, so each volume can back up to a different
+// S3 endpoint/bucket with its own credentials.
+func (s *DataStore) GetCredentialFromBackupTarget(backupTargetName string) (map[string]string, error) {
+	bt, err := s.GetBackupTarget(backupTargetName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get backup target %v to look up credential secret", backupTargetName)
+	}
+	if bt.Spec.CredentialSecret == "" {
+		return map[string]string{}, nil
+	}
+	return s.GetCredentialFromSecret(bt.Spec.CredentialSecret)
+}
+
 func checkVolume(v *longhorn.Volume) error {
 	size, err := util.ConvertSize(v.Spec.Size)
 	if err != nil {
@@ -255,16 +322,49 @@ func tagVolumeLabel(volumeName string, obj runtime.Object) error {
 	return nil
 }
 
-func fixupMetadata(volumeName string, obj runtime.Object) error {
+func (s *DataStore) fixupMetadata(volumeName string, obj runtime.Object) error {
 	if err := tagVolumeLabel(volumeName, obj); err != nil {
 		return err
 	}
+	if err := s.tagVolumeWorkloadLabels(volumeName, obj); err != nil {
+		return err
+	}
 	if err := util.AddFinalizer(longhornFinalizerKey, obj); err != nil {
 		return err
 	}
 	return nil
 }
 
+// tagVolumeWorkloadLabels enriches obj with longhorn.io/workload-kind and
+// longhorn.io/workload-name labels resolved via the OwnerResolver, so
+// monitoring and backup selectors can group Longhorn resources by the
+// application that owns them. A volume with no attached Pod (or no resolver
+// wired up yet) is left untagged rather than failing the whole fixup.
+func (s *DataStore) tagVolumeWorkloadLabels(volumeName string, obj runtime.Object) error {
+	if s.ownerResolver == nil {
+		return nil
+	}
+	workload, err := s.GetVolumeWorkload(volumeName)
+	if err != nil {
+		return err
+	}
+	if workload == nil {
+		return nil
+	}
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	labels := metadata.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[types.GetLonghornLabelKey(types.LonghornLabelWorkloadKind)] = workload.Kind
+	labels[types.GetLonghornLabelKey(types.LonghornLabelWorkloadName)] = workload.Name
+	metadata.SetLabels(labels)
+	return nil
+}
+
 func getVolumeSelector(volumeName string) (labels.Selector, error) {
 	return metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
 		MatchLabels: types.GetVolumeLabels(volumeName),
@@ -286,7 +386,7 @@ func (s *DataStore) CreateVolume(v *longhorn.Volume) (*longhorn.Volume, error) {
 	if err := checkVolume(v); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(v.Name, v); err != nil {
+	if err := s.fixupMetadata(v.Name, v); err != nil {
 		return nil, err
 	}
 	ret, err := s.lhClient.LonghornV1beta1().Volumes(s.namespace).Create(v)
@@ -314,7 +414,7 @@ func (s *DataStore) UpdateVolume(v *longhorn.Volume) (*longhorn.Volume, error) {
 	if err := checkVolume(v); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(v.Name, v); err != nil {
+	if err := s.fixupMetadata(v.Name, v); err != nil {
 		return nil, err
 	}
 
@@ -365,16 +465,11 @@ func (s *DataStore) RemoveFinalizerForVolume(obj *longhorn.Volume) error {
 }
 
 func (s *DataStore) getVolumeRO(name string) (*longhorn.Volume, error) {
-	return s.vLister.Volumes(s.namespace).Get(name)
+	return NewListerObjectDB(s).GetVolumeRO(name)
 }
 
 func (s *DataStore) GetVolume(name string) (*longhorn.Volume, error) {
-	resultRO, err := s.vLister.Volumes(s.namespace).Get(name)
-	if err != nil {
-		return nil, err
-	}
-	// Cannot use cached object from lister
-	return resultRO.DeepCopy(), nil
+	return NewListerObjectDB(s).GetVolume(name)
 }
 
 func (s *DataStore) ListVolumesRO() ([]*longhorn.Volume, error) {
@@ -412,6 +507,9 @@ func (s *DataStore) ListStandbyVolumesRO() (map[string]*longhorn.Volume, error)
 	return itemMap, nil
 }
 
+// checkEngine requires VolumeName to always be set because the by-volume
+// indexer in indexer.go keys off this field; an engine that slipped through
+// without it would be invisible to ListVolumeEnginesByIndex.
 func checkEngine(engine *longhorn.Engine) error {
 	if engine.Name == "" || engine.Spec.VolumeName == "" {
 		return fmt.Errorf("BUG: missing required field %+v", engine)
@@ -423,7 +521,7 @@ func (s *DataStore) CreateEngine(e *longhorn.Engine) (*longhorn.Engine, error) {
 	if err := checkEngine(e); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(e.Spec.VolumeName, e); err != nil {
+	if err := s.fixupMetadata(e.Spec.VolumeName, e); err != nil {
 		return nil, err
 	}
 	if err := tagNodeLabel(e.Spec.NodeID, e); err != nil {
@@ -456,7 +554,7 @@ func (s *DataStore) UpdateEngine(e *longhorn.Engine) (*longhorn.Engine, error) {
 	if err := checkEngine(e); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(e.Spec.VolumeName, e); err != nil {
+	if err := s.fixupMetadata(e.Spec.VolumeName, e); err != nil {
 		return nil, err
 	}
 	if err := tagNodeLabel(e.Spec.NodeID, e); err != nil {
@@ -510,20 +608,21 @@ func (s *DataStore) RemoveFinalizerForEngine(obj *longhorn.Engine) error {
 }
 
 func (s *DataStore) getEngineRO(name string) (*longhorn.Engine, error) {
-	return s.eLister.Engines(s.namespace).Get(name)
+	return NewListerObjectDB(s).GetEngineRO(name)
 }
 
 func (s *DataStore) getEngine(name string) (*longhorn.Engine, error) {
-	resultRO, err := s.getEngineRO(name)
-	if err != nil {
-		return nil, err
-	}
-	// Cannot use cached object from lister
-	return resultRO.DeepCopy(), nil
+	return NewListerObjectDB(s).GetEngine(name)
 }
 
+// GetEngine used to return the cached lister object directly instead of a
+// DeepCopy (unlike every sibling Get* method, including its own unexported
+// getEngine above), so a caller mutating the result -- e.g. node_controller's
+// reconcileInstanceManagerDrain unpinning Spec.InstanceManagerName ahead of
+// UpdateEngine -- was corrupting the shared informer cache. Routing through
+// ObjectDB fixes that for free since ObjectDB.GetEngine always DeepCopies.
 func (s *DataStore) GetEngine(name string) (*longhorn.Engine, error) {
-	return s.eLister.Engines(s.namespace).Get(name)
+	return NewListerObjectDB(s).GetEngine(name)
 }
 
 func (s *DataStore) listEngines(selector labels.Selector) (map[string]*longhorn.Engine, error) {
@@ -555,6 +654,9 @@ func (s *DataStore) ListVolumeEngines(volumeName string) (map[string]*longhorn.E
 	return s.listEngines(selector)
 }
 
+// checkReplica requires VolumeName to always be set for the same reason as
+// checkEngine: the by-volume indexer in indexer.go keys off it. NodeID is not
+// required, since the by-node indexer leaves a replica without one unindexed.
 func checkReplica(r *longhorn.Replica) error {
 	if r.Name == "" || r.Spec.VolumeName == "" {
 		return fmt.Errorf("BUG: missing required field %+v", r)
@@ -569,7 +671,7 @@ func (s *DataStore) CreateReplica(r *longhorn.Replica) (*longhorn.Replica, error
 	if err := checkReplica(r); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(r.Spec.VolumeName, r); err != nil {
+	if err := s.fixupMetadata(r.Spec.VolumeName, r); err != nil {
 		return nil, err
 	}
 	if err := tagNodeLabel(r.Spec.NodeID, r); err != nil {
@@ -602,7 +704,7 @@ func (s *DataStore) UpdateReplica(r *longhorn.Replica) (*longhorn.Replica, error
 	if err := checkReplica(r); err != nil {
 		return nil, err
 	}
-	if err := fixupMetadata(r.Spec.VolumeName, r); err != nil {
+	if err := s.fixupMetadata(r.Spec.VolumeName, r); err != nil {
 		return nil, err
 	}
 	if err := tagNodeLabel(r.Spec.NodeID, r); err != nil {
@@ -668,16 +770,11 @@ func (s *DataStore) GetReplica(name string) (*longhorn.Replica, error) {
 }
 
 func (s *DataStore) getReplicaRO(name string) (*longhorn.Replica, error) {
-	return s.rLister.Replicas(s.namespace).Get(name)
+	return NewListerObjectDB(s).GetReplicaRO(name)
 }
 
 func (s *DataStore) getReplica(name string) (*longhorn.Replica, error) {
-	resultRO, err := s.rLister.Replicas(s.namespace).Get(name)
-	if err != nil {
-		return nil, err
-	}
-	// Cannot use cached object from lister
-	return resultRO.DeepCopy(), nil
+	return NewListerObjectDB(s).GetReplica(name)
 }
 
 func (s *DataStore) listReplicas(selector labels.Selector) (map[string]*longhorn.Replica, error) {
@@ -970,26 +1067,8 @@ func (s *DataStore) ListNodes() (map[string]*longhorn.Node, error) {
 	return itemMap, nil
 }
 
-func (s *DataStore) GetRandomReadyNode() (*longhorn.Node, error) {
-	logrus.Debugf("Prepare to find a random ready node")
-	nodeList, err := s.ListNodes()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get random ready node")
-	}
-	var usableNode *longhorn.Node
-	for name := range nodeList {
-		node := nodeList[name]
-		readyCondition := types.GetCondition(node.Status.Conditions, types.NodeConditionTypeReady)
-		if readyCondition.Status == types.ConditionStatusTrue && node.Spec.AllowScheduling == true {
-			usableNode = node
-			break
-		}
-	}
-	if usableNode == nil {
-		return nil, fmt.Errorf("unable to get a ready node")
-	}
-	return usableNode, nil
-}
+// GetRandomReadyNode has moved to node_selector.go, where it's now a thin
+// wrapper around the policy-driven GetReadyNodesByPolicy selector.
 
 // RemoveFinalizerForNode will result in deletion if DeletionTimestamp was set
 func (s *DataStore) RemoveFinalizerForNode(obj *longhorn.Node) error {
@@ -1132,6 +1211,107 @@ func (s *DataStore) GetSettingAsBool(settingName types.SettingName) (bool, error
 	return false, fmt.Errorf("The %v setting value couldn't be converted to bool, value is %v ", string(settingName), value)
 }
 
+// GetSettingAsDuration parses a setting declared as types.SettingTypeInt as a
+// number of seconds, matching the convention used by settings like
+// engine-replica-timeout.
+func (s *DataStore) GetSettingAsDuration(settingName types.SettingName) (time.Duration, error) {
+	seconds, err := s.GetSettingAsInt(settingName)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// GetSettingAsStringList parses a setting declared as types.SettingTypeString
+// whose value is a comma-separated list (e.g. taint toleration keys), trimming
+// whitespace around each entry and dropping empty ones.
+func (s *DataStore) GetSettingAsStringList(settingName types.SettingName) ([]string, error) {
+	definition, ok := types.SettingDefinitions[settingName]
+	if !ok {
+		return nil, fmt.Errorf("setting %v is not supported", settingName)
+	}
+	if definition.Type != types.SettingTypeString {
+		return nil, fmt.Errorf("the %v setting value couldn't be converted to a string list, declared type is %v", settingName, definition.Type)
+	}
+	setting, err := s.GetSetting(settingName)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, item := range strings.Split(setting.Value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// GetSettingAsTolerations parses SettingNameTaintToleration (and anything
+// else declared as SettingTypeTolerations) into the typed []v1.Toleration
+// slice every caller currently has to derive by hand.
+func (s *DataStore) GetSettingAsTolerations(settingName types.SettingName) ([]v1.Toleration, error) {
+	definition, ok := types.SettingDefinitions[settingName]
+	if !ok {
+		return nil, fmt.Errorf("setting %v is not supported", settingName)
+	}
+	if definition.Type != types.SettingTypeTolerations {
+		return nil, fmt.Errorf("the %v setting value couldn't be converted to tolerations, declared type is %v", settingName, definition.Type)
+	}
+	setting, err := s.GetSetting(settingName)
+	if err != nil {
+		return nil, err
+	}
+	tolerations, err := types.UnmarshalTolerations(setting.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %v setting value %v as tolerations", settingName, setting.Value)
+	}
+	return tolerations, nil
+}
+
+// SettingValidatorFunc lets callers outside this package register
+// cross-object constraints for a specific setting (e.g. "all volumes must be
+// detached") without editing the switch statement in ValidateSetting.
+type SettingValidatorFunc func(s *DataStore, value string) error
+
+var settingValidators = map[types.SettingName]SettingValidatorFunc{}
+
+// RegisterSettingValidator adds a validator that ValidateSetting consults
+// in addition to its built-in switch cases. Intended to be called from
+// controller init code (backup, upgrade, node) so new cross-object rules
+// don't require changes here.
+func RegisterSettingValidator(name types.SettingName, validator SettingValidatorFunc) {
+	settingValidators[name] = validator
+}
+
+// settingChangeHandler is the callback signature for WatchSetting.
+type settingChangeHandler func(setting *longhorn.Setting)
+
+// WatchSetting lets controllers subscribe to changes of a single setting via
+// the shared settings informer instead of polling GetSetting on every
+// reconcile. The handler fires on both add and update events for the named
+// setting; callers that also care about the initial value should call
+// GetSetting once up front.
+func (s *DataStore) WatchSetting(name types.SettingName, handler settingChangeHandler) {
+	s.sInformer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			setting, ok := obj.(*longhorn.Setting)
+			if !ok {
+				return false
+			}
+			return types.SettingName(setting.Name) == name
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				handler(obj.(*longhorn.Setting).DeepCopy())
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				handler(newObj.(*longhorn.Setting).DeepCopy())
+			},
+		},
+	})
+}
+
 func (s *DataStore) ResetMonitoringEngineStatus(e *longhorn.Engine) (*longhorn.Engine, error) {
 	e.Status.Endpoint = ""
 	e.Status.LastRestoredBackup = ""
@@ -1261,46 +1441,155 @@ func (s *DataStore) ListInstanceManagersBySelector(node, instanceManagerImage st
 	return itemMap, nil
 }
 
+// GetInstanceManagerByInstance resolves the InstanceManager that should
+// handle obj (an Engine or Replica). Resolution order:
+//  1. Spec.InstanceManagerName, if the caller pinned one explicitly.
+//  2. The IM on the instance's node whose image matches the instance's own
+//     Spec.EngineImage (falling back to the cluster default instance
+//     manager image when that field is empty).
+//  3. If more than one IM still matches, prefer one already hosting a
+//     process for this instance (see ListInstanceManagersHosting), then the
+//     IM with the oldest CreationTimestamp for stability.
 func (s *DataStore) GetInstanceManagerByInstance(obj interface{}) (*longhorn.InstanceManager, error) {
 	var (
-		name, nodeID string
-		imType       types.InstanceManagerType
+		name, nodeID, pinnedIM, pinnedPool, preferredImage string
+		imType                                             types.InstanceManagerType
 	)
 
-	image, err := s.GetSettingValueExisted(types.SettingNameDefaultInstanceManagerImage)
+	switch o := obj.(type) {
+	case *longhorn.Engine:
+		name = o.Name
+		nodeID = o.Spec.NodeID
+		pinnedIM = o.Spec.InstanceManagerName
+		pinnedPool = o.Spec.InstanceManagerPool
+		preferredImage = o.Spec.EngineImage
+		imType = types.InstanceManagerTypeEngine
+	case *longhorn.Replica:
+		name = o.Name
+		nodeID = o.Spec.NodeID
+		pinnedIM = o.Spec.InstanceManagerName
+		pinnedPool = o.Spec.InstanceManagerPool
+		preferredImage = o.Spec.EngineImage
+		imType = types.InstanceManagerTypeReplica
+	default:
+		return nil, fmt.Errorf("unknown type for GetInstanceManagerByInstance, %+v", obj)
+	}
+	if nodeID == "" {
+		return nil, fmt.Errorf("invalid request for GetInstanceManagerByInstance: no NodeID specified for instance %v", name)
+	}
+
+	if pinnedIM != "" {
+		return s.GetInstanceManager(pinnedIM)
+	}
+
+	if preferredImage == "" {
+		var err error
+		preferredImage, err = s.GetSettingValueExisted(types.SettingNameDefaultInstanceManagerImage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	imMap, err := s.ListInstanceManagersBySelector(nodeID, preferredImage, imType)
+	if err != nil {
+		return nil, err
+	}
+	imMap = filterInstanceManagersByPool(imMap, pinnedPool)
+	if len(imMap) == 1 {
+		for _, im := range imMap {
+			return im, nil
+		}
+	}
+	if len(imMap) > 1 {
+		return pickInstanceManager(imMap, name)
+	}
+	return nil, fmt.Errorf("can not find the only available instance manager for instance %v, node %v, instance manager image %v, type %v, pool %v", name, nodeID, preferredImage, imType, pinnedPool)
+}
+
+// pickInstanceManager breaks a tie between multiple equally-eligible
+// InstanceManagers by first preferring one already hosting a process for
+// instanceName, then the one with the oldest CreationTimestamp.
+func pickInstanceManager(imMap map[string]*longhorn.InstanceManager, instanceName string) (*longhorn.InstanceManager, error) {
+	for _, im := range imMap {
+		if _, ok := im.Status.Instances[instanceName]; ok {
+			return im, nil
+		}
+	}
+
+	var oldest *longhorn.InstanceManager
+	for _, im := range imMap {
+		if oldest == nil || im.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = im
+		}
+	}
+	return oldest, nil
+}
+
+// ListInstanceManagersHosting scans every InstanceManager's
+// Status.Instances for an entry with the named instance, whatever that
+// entry's state. GetInstanceManagerByInstance does not call it; its
+// tie-break in pickInstanceManager applies the same presence check inline.
+func (s *DataStore) ListInstanceManagersHosting(instanceName string) (map[string]*longhorn.InstanceManager, error) {
+	all, err := s.ListInstanceManagers()
 	if err != nil {
 		return nil, err
 	}
+	hosting := map[string]*longhorn.InstanceManager{}
+	for name, im := range all {
+		if _, ok := im.Status.Instances[instanceName]; ok {
+			hosting[name] = im
+		}
+	}
+	return hosting, nil
+}
 
-	switch obj.(type) {
+// ChooseInstanceManagerForCreation is the scheduling-time counterpart to
+// GetInstanceManagerByInstance: controllers call it when first placing an
+// Engine/Replica so that initial placement and later lookups share the same
+// image-matching and tie-breaking rules instead of drifting apart.
+func (s *DataStore) ChooseInstanceManagerForCreation(obj interface{}, preferredImage string) (*longhorn.InstanceManager, error) {
+	var (
+		nodeID, pinnedPool string
+		imType             types.InstanceManagerType
+	)
+	switch o := obj.(type) {
 	case *longhorn.Engine:
-		engine := obj.(*longhorn.Engine)
-		name = engine.Name
-		nodeID = engine.Spec.NodeID
+		nodeID = o.Spec.NodeID
+		pinnedPool = o.Spec.InstanceManagerPool
 		imType = types.InstanceManagerTypeEngine
 	case *longhorn.Replica:
-		replica := obj.(*longhorn.Replica)
-		name = replica.Name
-		nodeID = replica.Spec.NodeID
+		nodeID = o.Spec.NodeID
+		pinnedPool = o.Spec.InstanceManagerPool
 		imType = types.InstanceManagerTypeReplica
 	default:
-		return nil, fmt.Errorf("unknown type for GetInstanceManagerByInstance, %+v", obj)
+		return nil, fmt.Errorf("unknown type for ChooseInstanceManagerForCreation, %+v", obj)
 	}
 	if nodeID == "" {
-		return nil, fmt.Errorf("invalid request for GetInstanceManagerByInstance: no NodeID specified for instance %v", name)
+		return nil, fmt.Errorf("invalid request for ChooseInstanceManagerForCreation: no NodeID specified")
+	}
+	if preferredImage == "" {
+		var err error
+		preferredImage, err = s.GetSettingValueExisted(types.SettingNameDefaultInstanceManagerImage)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	imMap, err := s.ListInstanceManagersBySelector(nodeID, image, imType)
+	imMap, err := s.ListInstanceManagersBySelector(nodeID, preferredImage, imType)
 	if err != nil {
 		return nil, err
 	}
+	imMap = filterInstanceManagersByPool(imMap, pinnedPool)
+	imMap = filterCordonedInstanceManagers(imMap)
+	if len(imMap) == 0 {
+		return nil, fmt.Errorf("no instance manager available on node %v for image %v, type %v, pool %v", nodeID, preferredImage, imType, pinnedPool)
+	}
 	if len(imMap) == 1 {
 		for _, im := range imMap {
 			return im, nil
 		}
-
 	}
-	return nil, fmt.Errorf("can not find the only available instance manager for instance %v, node %v, instance manager image %v, type %v", name, nodeID, image, imType)
+	return pickInstanceManager(imMap, "")
 }
 
 func (s *DataStore) ListInstanceManagersByNode(node string, imType types.InstanceManagerType) (map[string]*longhorn.InstanceManager, error) {
@@ -1368,6 +1657,171 @@ func (s *DataStore) UpdateInstanceManagerStatus(im *longhorn.InstanceManager) (*
 	return obj, nil
 }
 
+// GetOwnerReferencesForInstanceManagerPool builds the OwnerReference Longhorn
+// CRs created from an InstanceManagerPool (the per-node InstanceManagers it
+// reconciles) carry, the same way InstanceManager-owned objects do.
+func GetOwnerReferencesForInstanceManagerPool(pool *longhorn.InstanceManagerPool) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: longhorn.SchemeGroupVersion.String(),
+			Kind:       types.LonghornKindInstanceManagerPool,
+			Name:       pool.Name,
+			UID:        pool.UID,
+		},
+	}
+}
+
+func (s *DataStore) CreateInstanceManagerPool(pool *longhorn.InstanceManagerPool) (*longhorn.InstanceManagerPool, error) {
+	if err := util.AddFinalizer(longhornFinalizerKey, pool); err != nil {
+		return nil, err
+	}
+	ret, err := s.lhClient.LonghornV1beta1().InstanceManagerPools(s.namespace).Create(pool)
+	if err != nil {
+		return nil, err
+	}
+	if SkipListerCheck {
+		return ret, nil
+	}
+
+	obj, err := verifyCreation(pool.Name, "instance manager pool", func(name string) (runtime.Object, error) {
+		return s.getInstanceManagerPoolRO(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := obj.(*longhorn.InstanceManagerPool)
+	if !ok {
+		return nil, fmt.Errorf("BUG: datastore: verifyCreation returned wrong type for instance manager pool")
+	}
+
+	return ret, nil
+}
+
+func (s *DataStore) getInstanceManagerPoolRO(name string) (*longhorn.InstanceManagerPool, error) {
+	return s.impLister.InstanceManagerPools(s.namespace).Get(name)
+}
+
+func (s *DataStore) GetInstanceManagerPool(name string) (*longhorn.InstanceManagerPool, error) {
+	resultRO, err := s.getInstanceManagerPoolRO(name)
+	if err != nil {
+		return nil, err
+	}
+	return resultRO.DeepCopy(), nil
+}
+
+// ListInstanceManagerPools returns every InstanceManagerPool in the
+// namespace; syncInstanceManagers filters this down to the ones eligible for
+// a given node via each pool's NodeSelector.
+func (s *DataStore) ListInstanceManagerPools() (map[string]*longhorn.InstanceManagerPool, error) {
+	itemMap := map[string]*longhorn.InstanceManagerPool{}
+
+	list, err := s.impLister.InstanceManagerPools(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+// DeleteInstanceManagerPool won't result in immediate deletion since a
+// finalizer was set by default.
+func (s *DataStore) DeleteInstanceManagerPool(name string) error {
+	return s.lhClient.LonghornV1beta1().InstanceManagerPools(s.namespace).Delete(name, &metav1.DeleteOptions{})
+}
+
+// RemoveFinalizerForInstanceManagerPool will result in deletion if
+// DeletionTimestamp was set.
+func (s *DataStore) RemoveFinalizerForInstanceManagerPool(obj *longhorn.InstanceManagerPool) error {
+	if !util.FinalizerExists(longhornFinalizerKey, obj) {
+		// finalizer already removed
+		return nil
+	}
+	if err := util.RemoveFinalizer(longhornFinalizerKey, obj); err != nil {
+		return err
+	}
+	_, err := s.lhClient.LonghornV1beta1().InstanceManagerPools(s.namespace).Update(obj)
+	if err != nil {
+		// workaround `StorageError: invalid object, Code: 4` due to empty object
+		if obj.DeletionTimestamp != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to remove finalizer for instance manager pool %v", obj.Name)
+	}
+	return nil
+}
+
+func (s *DataStore) UpdateInstanceManagerPoolStatus(pool *longhorn.InstanceManagerPool) (*longhorn.InstanceManagerPool, error) {
+	obj, err := s.lhClient.LonghornV1beta1().InstanceManagerPools(s.namespace).UpdateStatus(pool)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(pool.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getInstanceManagerPoolRO(name)
+	})
+	return obj, nil
+}
+
+// ListInstanceManagerPoolsForNode returns the pools whose Spec.NodeSelector
+// matches node's labels, the same matching semantics a Kubernetes
+// NodeSelector has. A pool with an empty/nil NodeSelector matches every
+// node, the same way the implicit default pool did before pools existed.
+func (s *DataStore) ListInstanceManagerPoolsForNode(node *longhorn.Node) (map[string]*longhorn.InstanceManagerPool, error) {
+	all, err := s.ListInstanceManagerPools()
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := map[string]*longhorn.InstanceManagerPool{}
+	for name, pool := range all {
+		selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+			MatchLabels: pool.Spec.NodeSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if selector.Matches(labels.Set(node.Labels)) {
+			eligible[name] = pool
+		}
+	}
+	return eligible, nil
+}
+
+// filterInstanceManagersByPool narrows imMap down to the InstanceManagers
+// labeled for poolName, so a Volume/Replica/Engine that pinned itself to a
+// specific pool only ever resolves to that pool's InstanceManagers.
+func filterInstanceManagersByPool(imMap map[string]*longhorn.InstanceManager, poolName string) map[string]*longhorn.InstanceManager {
+	if poolName == "" {
+		return imMap
+	}
+	filtered := map[string]*longhorn.InstanceManager{}
+	poolLabelKey := types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerPool)
+	for name, im := range imMap {
+		if im.Labels[poolLabelKey] == poolName {
+			filtered[name] = im
+		}
+	}
+	return filtered
+}
+
+// filterCordonedInstanceManagers drops InstanceManagers that are being
+// drained (Spec.Cordoned) from consideration for new instance placement --
+// ChooseInstanceManagerForCreation only, since an instance already running
+// on a cordoned IM should keep running there until the drain evicts it.
+func filterCordonedInstanceManagers(imMap map[string]*longhorn.InstanceManager) map[string]*longhorn.InstanceManager {
+	filtered := map[string]*longhorn.InstanceManager{}
+	for name, im := range imMap {
+		if im.Spec.Cordoned {
+			continue
+		}
+		filtered[name] = im
+	}
+	return filtered
+}
+
 func verifyCreation(name, kind string, getMethod func(name string) (runtime.Object, error)) (runtime.Object, error) {
 	// WORKAROUND: The immedidate read after object's creation can fail.
 	// See https://github.com/longhorn/longhorn/issues/133
@@ -1473,3 +1927,406 @@ func (s *DataStore) IsEngineImageCLIAPIVersionLessThanThree(imageName string) (b
 	}
 	return false, nil
 }
+
+func checkVolumeSnapshot(vs *longhorn.VolumeSnapshot) error {
+	if vs.Name == "" {
+		return fmt.Errorf("BUG: missing required field %+v", vs)
+	}
+	if vs.Spec.Source.PersistentVolumeClaimName == "" && vs.Spec.Source.VolumeSnapshotContentName == "" {
+		return fmt.Errorf("BUG: volume snapshot %v is missing a source PVC or content reference", vs.Name)
+	}
+	errs := validation.IsDNS1123Label(vs.Name)
+	if len(errs) != 0 {
+		return fmt.Errorf("Invalid volume snapshot name: %+v", errs)
+	}
+	if len(vs.Name) > NameMaximumLength {
+		return fmt.Errorf("Volume snapshot name is too long %v, must be less than %v characters",
+			vs.Name, NameMaximumLength)
+	}
+	return nil
+}
+
+func (s *DataStore) CreateVolumeSnapshot(vs *longhorn.VolumeSnapshot) (*longhorn.VolumeSnapshot, error) {
+	if err := checkVolumeSnapshot(vs); err != nil {
+		return nil, err
+	}
+	if err := util.AddFinalizer(longhornFinalizerKey, vs); err != nil {
+		return nil, err
+	}
+	ret, err := s.lhClient.LonghornV1beta1().VolumeSnapshots(s.namespace).Create(vs)
+	if err != nil {
+		return nil, err
+	}
+	if SkipListerCheck {
+		return ret, nil
+	}
+
+	obj, err := verifyCreation(vs.Name, "volume snapshot", func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotRO(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := obj.(*longhorn.VolumeSnapshot)
+	if !ok {
+		return nil, fmt.Errorf("BUG: datastore: verifyCreation returned wrong type for volume snapshot")
+	}
+	return ret, nil
+}
+
+func (s *DataStore) UpdateVolumeSnapshot(vs *longhorn.VolumeSnapshot) (*longhorn.VolumeSnapshot, error) {
+	if err := checkVolumeSnapshot(vs); err != nil {
+		return nil, err
+	}
+
+	obj, err := s.lhClient.LonghornV1beta1().VolumeSnapshots(s.namespace).Update(vs)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(vs.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotRO(name)
+	})
+	return obj, nil
+}
+
+func (s *DataStore) UpdateVolumeSnapshotStatus(vs *longhorn.VolumeSnapshot) (*longhorn.VolumeSnapshot, error) {
+	obj, err := s.lhClient.LonghornV1beta1().VolumeSnapshots(s.namespace).UpdateStatus(vs)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(vs.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotRO(name)
+	})
+	return obj, nil
+}
+
+// DeleteVolumeSnapshot won't result in immediately deletion since finalizer was set by default
+func (s *DataStore) DeleteVolumeSnapshot(name string) error {
+	return s.lhClient.LonghornV1beta1().VolumeSnapshots(s.namespace).Delete(name, &metav1.DeleteOptions{})
+}
+
+// RemoveFinalizerForVolumeSnapshot will result in deletion if DeletionTimestamp was set
+func (s *DataStore) RemoveFinalizerForVolumeSnapshot(obj *longhorn.VolumeSnapshot) error {
+	if !util.FinalizerExists(longhornFinalizerKey, obj) {
+		// finalizer already removed
+		return nil
+	}
+	if err := util.RemoveFinalizer(longhornFinalizerKey, obj); err != nil {
+		return err
+	}
+	_, err := s.lhClient.LonghornV1beta1().VolumeSnapshots(s.namespace).Update(obj)
+	if err != nil {
+		// workaround `StorageError: invalid object, Code: 4` due to empty object
+		if obj.DeletionTimestamp != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to remove finalizer for volume snapshot %v", obj.Name)
+	}
+	return nil
+}
+
+func (s *DataStore) getVolumeSnapshotRO(name string) (*longhorn.VolumeSnapshot, error) {
+	return s.vsLister.VolumeSnapshots(s.namespace).Get(name)
+}
+
+func (s *DataStore) GetVolumeSnapshot(name string) (*longhorn.VolumeSnapshot, error) {
+	resultRO, err := s.getVolumeSnapshotRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+func (s *DataStore) ListVolumeSnapshots() (map[string]*longhorn.VolumeSnapshot, error) {
+	itemMap := make(map[string]*longhorn.VolumeSnapshot)
+
+	list, err := s.vsLister.VolumeSnapshots(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+func checkVolumeSnapshotContent(vsc *longhorn.VolumeSnapshotContent) error {
+	if vsc.Name == "" {
+		return fmt.Errorf("BUG: missing required field %+v", vsc)
+	}
+	if vsc.Spec.Source.VolumeHandle == "" && vsc.Spec.Source.SnapshotHandle == "" {
+		return fmt.Errorf("BUG: volume snapshot content %v is missing a volume or snapshot handle", vsc.Name)
+	}
+	if vsc.Spec.DriverName != types.LonghornDriverName {
+		return fmt.Errorf("volume snapshot content %v has unsupported driver %v, expected %v",
+			vsc.Name, vsc.Spec.DriverName, types.LonghornDriverName)
+	}
+	return nil
+}
+
+// CreateVolumeSnapshotContent tags the cluster-scoped object with the owning
+// volume's labels (via tagVolumeLabel) so a controller can later correlate
+// content objects back to the Longhorn volume they snapshot, and sets the
+// finalizer so the underlying Longhorn snapshot can be cleaned up on delete.
+func (s *DataStore) CreateVolumeSnapshotContent(volumeName string, vsc *longhorn.VolumeSnapshotContent) (*longhorn.VolumeSnapshotContent, error) {
+	if err := checkVolumeSnapshotContent(vsc); err != nil {
+		return nil, err
+	}
+	if err := tagVolumeLabel(volumeName, vsc); err != nil {
+		return nil, err
+	}
+	if err := util.AddFinalizer(longhornFinalizerKey, vsc); err != nil {
+		return nil, err
+	}
+
+	ret, err := s.lhClient.LonghornV1beta1().VolumeSnapshotContents().Create(vsc)
+	if err != nil {
+		return nil, err
+	}
+	if SkipListerCheck {
+		return ret, nil
+	}
+
+	obj, err := verifyCreation(vsc.Name, "volume snapshot content", func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotContentRO(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := obj.(*longhorn.VolumeSnapshotContent)
+	if !ok {
+		return nil, fmt.Errorf("BUG: datastore: verifyCreation returned wrong type for volume snapshot content")
+	}
+	return ret, nil
+}
+
+func (s *DataStore) UpdateVolumeSnapshotContent(vsc *longhorn.VolumeSnapshotContent) (*longhorn.VolumeSnapshotContent, error) {
+	if err := checkVolumeSnapshotContent(vsc); err != nil {
+		return nil, err
+	}
+
+	obj, err := s.lhClient.LonghornV1beta1().VolumeSnapshotContents().Update(vsc)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(vsc.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotContentRO(name)
+	})
+	return obj, nil
+}
+
+func (s *DataStore) UpdateVolumeSnapshotContentStatus(vsc *longhorn.VolumeSnapshotContent) (*longhorn.VolumeSnapshotContent, error) {
+	obj, err := s.lhClient.LonghornV1beta1().VolumeSnapshotContents().UpdateStatus(vsc)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(vsc.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getVolumeSnapshotContentRO(name)
+	})
+	return obj, nil
+}
+
+// DeleteVolumeSnapshotContent won't result in immediately deletion since finalizer was set by default
+func (s *DataStore) DeleteVolumeSnapshotContent(name string) error {
+	return s.lhClient.LonghornV1beta1().VolumeSnapshotContents().Delete(name, &metav1.DeleteOptions{})
+}
+
+// RemoveFinalizerForVolumeSnapshotContent will result in deletion if DeletionTimestamp was set
+func (s *DataStore) RemoveFinalizerForVolumeSnapshotContent(obj *longhorn.VolumeSnapshotContent) error {
+	if !util.FinalizerExists(longhornFinalizerKey, obj) {
+		// finalizer already removed
+		return nil
+	}
+	if err := util.RemoveFinalizer(longhornFinalizerKey, obj); err != nil {
+		return err
+	}
+	_, err := s.lhClient.LonghornV1beta1().VolumeSnapshotContents().Update(obj)
+	if err != nil {
+		// workaround `StorageError: invalid object, Code: 4` due to empty object
+		if obj.DeletionTimestamp != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to remove finalizer for volume snapshot content %v", obj.Name)
+	}
+	return nil
+}
+
+func (s *DataStore) getVolumeSnapshotContentRO(name string) (*longhorn.VolumeSnapshotContent, error) {
+	return s.vscLister.Get(name)
+}
+
+func (s *DataStore) GetVolumeSnapshotContent(name string) (*longhorn.VolumeSnapshotContent, error) {
+	resultRO, err := s.getVolumeSnapshotContentRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+func (s *DataStore) ListVolumeSnapshotContents() (map[string]*longhorn.VolumeSnapshotContent, error) {
+	itemMap := make(map[string]*longhorn.VolumeSnapshotContent)
+
+	list, err := s.vscLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
+}
+
+// GetVolumeSnapshotClass looks up the VolumeSnapshotClass used to parameterize
+// a VolumeSnapshot (backup target, compression, incremental), mirroring the
+// read-only Setting lookup pattern above since snapshot classes are
+// cluster-scoped and immutable once referenced.
+func (s *DataStore) GetVolumeSnapshotClass(name string) (*longhorn.VolumeSnapshotClass, error) {
+	resultRO, err := s.vscClassLister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return resultRO.DeepCopy(), nil
+}
+
+func checkBackupTarget(bt *longhorn.BackupTarget) error {
+	if bt.Name == "" {
+		return fmt.Errorf("BUG: missing required field %+v", bt)
+	}
+	errs := validation.IsDNS1123Label(bt.Name)
+	if len(errs) != 0 {
+		return fmt.Errorf("Invalid backup target name: %+v", errs)
+	}
+	if len(bt.Name) > NameMaximumLength {
+		return fmt.Errorf("Backup target name is too long %v, must be less than %v characters",
+			bt.Name, NameMaximumLength)
+	}
+	return nil
+}
+
+func (s *DataStore) CreateBackupTarget(bt *longhorn.BackupTarget) (*longhorn.BackupTarget, error) {
+	if err := checkBackupTarget(bt); err != nil {
+		return nil, err
+	}
+	if err := util.AddFinalizer(longhornFinalizerKey, bt); err != nil {
+		return nil, err
+	}
+	ret, err := s.lhClient.LonghornV1beta1().BackupTargets(s.namespace).Create(bt)
+	if err != nil {
+		return nil, err
+	}
+	if SkipListerCheck {
+		return ret, nil
+	}
+
+	obj, err := verifyCreation(bt.Name, "backup target", func(name string) (runtime.Object, error) {
+		return s.getBackupTargetRO(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := obj.(*longhorn.BackupTarget)
+	if !ok {
+		return nil, fmt.Errorf("BUG: datastore: verifyCreation returned wrong type for backup target")
+	}
+	return ret, nil
+}
+
+func (s *DataStore) UpdateBackupTarget(bt *longhorn.BackupTarget) (*longhorn.BackupTarget, error) {
+	if err := checkBackupTarget(bt); err != nil {
+		return nil, err
+	}
+
+	obj, err := s.lhClient.LonghornV1beta1().BackupTargets(s.namespace).Update(bt)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(bt.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getBackupTargetRO(name)
+	})
+	return obj, nil
+}
+
+func (s *DataStore) UpdateBackupTargetStatus(bt *longhorn.BackupTarget) (*longhorn.BackupTarget, error) {
+	obj, err := s.lhClient.LonghornV1beta1().BackupTargets(s.namespace).UpdateStatus(bt)
+	if err != nil {
+		return nil, err
+	}
+	verifyUpdate(bt.Name, obj, func(name string) (runtime.Object, error) {
+		return s.getBackupTargetRO(name)
+	})
+	return obj, nil
+}
+
+// DeleteBackupTarget refuses to delete a BackupTarget that any volume still
+// references via Spec.BackupTargetName, since that would otherwise strand
+// the volume's next backup/restore cycle with a dangling reference.
+func (s *DataStore) DeleteBackupTarget(name string) error {
+	volumes, err := s.ListVolumesRO()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list volumes before deleting backup target %v", name)
+	}
+	referencing := []string{}
+	for _, v := range volumes {
+		if v.Spec.BackupTargetName == name {
+			referencing = append(referencing, v.Name)
+		}
+	}
+	if len(referencing) != 0 {
+		return fmt.Errorf("cannot delete backup target %v since it's still referenced by volumes: %v", name, referencing)
+	}
+	return s.lhClient.LonghornV1beta1().BackupTargets(s.namespace).Delete(name, &metav1.DeleteOptions{})
+}
+
+// RemoveFinalizerForBackupTarget will result in deletion if DeletionTimestamp was set
+func (s *DataStore) RemoveFinalizerForBackupTarget(obj *longhorn.BackupTarget) error {
+	if !util.FinalizerExists(longhornFinalizerKey, obj) {
+		// finalizer already removed
+		return nil
+	}
+	if err := util.RemoveFinalizer(longhornFinalizerKey, obj); err != nil {
+		return err
+	}
+	_, err := s.lhClient.LonghornV1beta1().BackupTargets(s.namespace).Update(obj)
+	if err != nil {
+		// workaround `StorageError: invalid object, Code: 4` due to empty object
+		if obj.DeletionTimestamp != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to remove finalizer for backup target %v", obj.Name)
+	}
+	return nil
+}
+
+func (s *DataStore) getBackupTargetRO(name string) (*longhorn.BackupTarget, error) {
+	return s.btLister.BackupTargets(s.namespace).Get(name)
+}
+
+func (s *DataStore) GetBackupTarget(name string) (*longhorn.BackupTarget, error) {
+	resultRO, err := s.getBackupTargetRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+func (s *DataStore) ListBackupTargets() (map[string]*longhorn.BackupTarget, error) {
+	itemMap := make(map[string]*longhorn.BackupTarget)
+
+	list, err := s.btLister.BackupTargets(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, itemRO := range list {
+		// Cannot use cached object from lister
+		itemMap[itemRO.Name] = itemRO.DeepCopy()
+	}
+	return itemMap, nil
+}
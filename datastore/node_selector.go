@@ -0,0 +1,239 @@
+package datastore
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+)
+
+// NodeSelectionPolicy picks which scoring/filtering strategy
+// GetReadyNodesByPolicy uses to rank candidate nodes for a new replica or
+// engine. The zero value "" is ranked the same way as
+// NodeSelectionPolicyLeastAllocated.
+type NodeSelectionPolicy string
+
+const (
+	NodeSelectionPolicyLeastAllocated   NodeSelectionPolicy = "least-allocated"
+	NodeSelectionPolicyZoneAntiAffinity NodeSelectionPolicy = "zone-anti-affinity"
+	NodeSelectionPolicyTagAffinity      NodeSelectionPolicy = "tag-affinity"
+	NodeSelectionPolicyWeightedRandom   NodeSelectionPolicy = "weighted-random"
+)
+
+// SelectionRequest carries everything a NodeSelectionPolicy needs to score
+// candidate nodes for one more replica of a volume.
+type SelectionRequest struct {
+	VolumeName string
+	// ReplicaCountByNode/ReplicaCountByZone let the caller avoid recomputing
+	// existing placement on every call (ListReplicasByNode already does the
+	// per-node version; zone counts are derived from it plus node topology).
+	ReplicaCountByNode map[string]int
+	ReplicaCountByZone map[string]int
+	RequiredTags       []string
+	PreferredTags      []string
+	MinimumFreeBytes   int64
+	HardZoneAntiAffinity bool
+}
+
+// nodeScore pairs a candidate node with the score a policy assigned it;
+// lower is better, matching the existing GetDiskSchedulingInfo convention of
+// treating smaller "pressure" numbers as more schedulable.
+type nodeScore struct {
+	node  *longhorn.Node
+	score float64
+}
+
+// GetReadyNodesByPolicy replaces the old GetRandomReadyNode with a
+// capacity/topology-aware ranking, deterministic for every policy except
+// weighted-random, which adds a small random jitter. It always filters out
+// nodes that are not Ready and not schedulable first, then scores the
+// remainder according to policy and returns them best-first.
+func (s *DataStore) GetReadyNodesByPolicy(policy NodeSelectionPolicy, req SelectionRequest) ([]*longhorn.Node, error) {
+	nodeList, err := s.ListNodes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list nodes for policy %v", policy)
+	}
+
+	candidates := make([]*longhorn.Node, 0, len(nodeList))
+	for _, node := range nodeList {
+		readyCondition := types.GetCondition(node.Status.Conditions, types.NodeConditionTypeReady)
+		if readyCondition.Status != types.ConditionStatusTrue || !node.Spec.AllowScheduling {
+			continue
+		}
+		if !nodeHasTags(node, req.RequiredTags) {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("unable to find any ready, schedulable node matching the request")
+	}
+
+	var scores []nodeScore
+	switch policy {
+	case NodeSelectionPolicyZoneAntiAffinity:
+		scores, err = s.scoreByZoneAntiAffinity(candidates, req)
+	case NodeSelectionPolicyTagAffinity:
+		scores = scoreByTagAffinity(candidates, req)
+	case NodeSelectionPolicyWeightedRandom:
+		scores, err = s.scoreWeightedRandom(candidates, req)
+	case NodeSelectionPolicyLeastAllocated, "":
+		scores, err = s.scoreByLeastAllocated(candidates, req)
+	default:
+		return nil, fmt.Errorf("unknown node selection policy %v", policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score < scores[j].score })
+	result := make([]*longhorn.Node, len(scores))
+	for i, sc := range scores {
+		result[i] = sc.node
+	}
+	return result, nil
+}
+
+func nodeHasTags(node *longhorn.Node, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	have := map[string]bool{}
+	for _, t := range node.Spec.Tags {
+		have[t] = true
+	}
+	for _, t := range required {
+		if !have[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreByLeastAllocated ranks nodes by sum(replica.Spec.VolumeSize) /
+// schedulable disk capacity, reusing ListReplicasByNode and the disk status
+// already maintained by NodeController.syncDiskStatus.
+func (s *DataStore) scoreByLeastAllocated(candidates []*longhorn.Node, req SelectionRequest) ([]nodeScore, error) {
+	scores := make([]nodeScore, 0, len(candidates))
+	for _, node := range candidates {
+		var totalCapacity, totalScheduled int64
+		for _, disk := range node.Status.DiskStatus {
+			totalCapacity += disk.StorageMaximum
+			totalScheduled += disk.StorageScheduled
+		}
+		if totalCapacity-totalScheduled < req.MinimumFreeBytes {
+			continue
+		}
+		allocation := 0.0
+		if totalCapacity > 0 {
+			allocation = float64(totalScheduled) / float64(totalCapacity)
+		}
+		scores = append(scores, nodeScore{node: node, score: allocation})
+	}
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("no node has at least %v bytes free", req.MinimumFreeBytes)
+	}
+	return scores, nil
+}
+
+// scoreByZoneAntiAffinity prefers nodes in a zone that doesn't already host a
+// replica of req.VolumeName. With HardZoneAntiAffinity set, zones already in
+// use are excluded outright instead of merely deprioritized.
+func (s *DataStore) scoreByZoneAntiAffinity(candidates []*longhorn.Node, req SelectionRequest) ([]nodeScore, error) {
+	scores := make([]nodeScore, 0, len(candidates))
+	for _, node := range candidates {
+		zone := node.Status.Zone
+		count := req.ReplicaCountByZone[zone]
+		if req.HardZoneAntiAffinity && count > 0 {
+			continue
+		}
+		scores = append(scores, nodeScore{node: node, score: float64(count)})
+	}
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("no node satisfies hard zone anti-affinity for volume %v", req.VolumeName)
+	}
+	return scores, nil
+}
+
+// scoreByTagAffinity ranks nodes by how many preferred tags their
+// node.Spec.Tags carry; RequiredTags are already enforced as a hard filter
+// above. Tags set on a node's disks are not counted, here or in that filter.
+func scoreByTagAffinity(candidates []*longhorn.Node, req SelectionRequest) []nodeScore {
+	scores := make([]nodeScore, 0, len(candidates))
+	for _, node := range candidates {
+		have := map[string]bool{}
+		for _, t := range node.Spec.Tags {
+			have[t] = true
+		}
+		matched := 0
+		for _, t := range req.PreferredTags {
+			if have[t] {
+				matched++
+			}
+		}
+		// Fewer unmatched preferred tags sorts first.
+		scores = append(scores, nodeScore{node: node, score: float64(len(req.PreferredTags) - matched)})
+	}
+	return scores
+}
+
+// scoreWeightedRandom combines least-allocated and zone-anti-affinity scores
+// with a small random jitter so that, among near-equally-good candidates,
+// placement doesn't always pick the same node (avoiding pathological
+// clustering when many volumes are created back to back).
+func (s *DataStore) scoreWeightedRandom(candidates []*longhorn.Node, req SelectionRequest) ([]nodeScore, error) {
+	allocationScores, err := s.scoreByLeastAllocated(candidates, req)
+	if err != nil {
+		return nil, err
+	}
+	zoneScores, err := s.scoreByZoneAntiAffinity(candidates, req)
+	if err != nil {
+		return nil, err
+	}
+	zoneByNode := map[string]float64{}
+	for _, sc := range zoneScores {
+		zoneByNode[sc.node.Name] = sc.score
+	}
+	result := make([]nodeScore, 0, len(allocationScores))
+	for _, sc := range allocationScores {
+		combined := sc.score + zoneByNode[sc.node.Name] + rand.Float64()*0.01
+		result = append(result, nodeScore{node: sc.node, score: combined})
+	}
+	return result, nil
+}
+
+// DefaultNodeSelectionPolicy reads the replica-node-selection-policy setting
+// and falls back to least-allocated if it's unset or invalid, matching the
+// convention other typed setting getters use for a safe zero value.
+func (s *DataStore) DefaultNodeSelectionPolicy() (NodeSelectionPolicy, error) {
+	value, err := s.GetSettingValueExisted(types.SettingNameReplicaNodeSelectionPolicy)
+	if err != nil {
+		return NodeSelectionPolicyLeastAllocated, nil
+	}
+	switch NodeSelectionPolicy(value) {
+	case NodeSelectionPolicyLeastAllocated, NodeSelectionPolicyZoneAntiAffinity, NodeSelectionPolicyTagAffinity, NodeSelectionPolicyWeightedRandom:
+		return NodeSelectionPolicy(value), nil
+	default:
+		return NodeSelectionPolicyLeastAllocated, nil
+	}
+}
+
+// GetRandomReadyNode is retained for any external callers still holding onto
+// the old signature, now implemented as a thin wrapper around the new
+// default-policy selector instead of a raw Go map iteration.
+func (s *DataStore) GetRandomReadyNode() (*longhorn.Node, error) {
+	policy, err := s.DefaultNodeSelectionPolicy()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.GetReadyNodesByPolicy(policy, SelectionRequest{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get random ready node")
+	}
+	return nodes[0], nil
+}
@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+)
+
+// ObjectDB abstracts the single-object Volume/Engine/Replica reads DataStore
+// otherwise hand-rolls inline against s.vLister/s.eLister/s.rLister, so a
+// caller that only needs "get me this one object" can depend on a narrow
+// interface instead of the whole DataStore, and so tests can substitute an
+// in-memory fake instead of standing up real listers. The *RO variants
+// return the cached lister object directly (callers must not mutate it);
+// the non-RO variants return a DeepCopy safe to mutate and pass to an
+// Update* call.
+type ObjectDB interface {
+	GetVolumeRO(name string) (*longhorn.Volume, error)
+	GetVolume(name string) (*longhorn.Volume, error)
+	GetEngineRO(name string) (*longhorn.Engine, error)
+	GetEngine(name string) (*longhorn.Engine, error)
+	GetReplicaRO(name string) (*longhorn.Replica, error)
+	GetReplica(name string) (*longhorn.Replica, error)
+}
+
+// listerObjectDB is the production ObjectDB, backed by the same listers the
+// rest of DataStore already holds. It's built fresh from a *DataStore rather
+// than stored on one, since DataStore's own Get* methods (in longhorn.go) are
+// themselves its only production callers.
+type listerObjectDB struct {
+	ds *DataStore
+}
+
+// NewListerObjectDB returns the lister-backed ObjectDB for ds.
+func NewListerObjectDB(ds *DataStore) ObjectDB {
+	return &listerObjectDB{ds: ds}
+}
+
+func (o *listerObjectDB) GetVolumeRO(name string) (*longhorn.Volume, error) {
+	return o.ds.vLister.Volumes(o.ds.namespace).Get(name)
+}
+
+func (o *listerObjectDB) GetVolume(name string) (*longhorn.Volume, error) {
+	resultRO, err := o.GetVolumeRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+func (o *listerObjectDB) GetEngineRO(name string) (*longhorn.Engine, error) {
+	return o.ds.eLister.Engines(o.ds.namespace).Get(name)
+}
+
+func (o *listerObjectDB) GetEngine(name string) (*longhorn.Engine, error) {
+	resultRO, err := o.GetEngineRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+func (o *listerObjectDB) GetReplicaRO(name string) (*longhorn.Replica, error) {
+	return o.ds.rLister.Replicas(o.ds.namespace).Get(name)
+}
+
+func (o *listerObjectDB) GetReplica(name string) (*longhorn.Replica, error) {
+	resultRO, err := o.GetReplicaRO(name)
+	if err != nil {
+		return nil, err
+	}
+	// Cannot use cached object from lister
+	return resultRO.DeepCopy(), nil
+}
+
+// memoryObjectDB is an in-memory ObjectDB for tests that want to exercise
+// code depending on ObjectDB without standing up a fake clientset/listers.
+type memoryObjectDB struct {
+	volumes  map[string]*longhorn.Volume
+	engines  map[string]*longhorn.Engine
+	replicas map[string]*longhorn.Replica
+}
+
+// NewMemoryObjectDB returns an ObjectDB backed by the given objects, keyed by
+// name, with no namespacing or label-selector support -- just enough for a
+// test to seed a few objects and exercise a single Get call.
+func NewMemoryObjectDB(volumes map[string]*longhorn.Volume, engines map[string]*longhorn.Engine, replicas map[string]*longhorn.Replica) ObjectDB {
+	return &memoryObjectDB{volumes: volumes, engines: engines, replicas: replicas}
+}
+
+func (o *memoryObjectDB) GetVolumeRO(name string) (*longhorn.Volume, error) {
+	if v, ok := o.volumes[name]; ok {
+		return v, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: longhorn.SchemeGroupVersion.Group, Resource: "volumes"}, name)
+}
+
+func (o *memoryObjectDB) GetVolume(name string) (*longhorn.Volume, error) {
+	resultRO, err := o.GetVolumeRO(name)
+	if err != nil {
+		return nil, err
+	}
+	return resultRO.DeepCopy(), nil
+}
+
+func (o *memoryObjectDB) GetEngineRO(name string) (*longhorn.Engine, error) {
+	if e, ok := o.engines[name]; ok {
+		return e, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: longhorn.SchemeGroupVersion.Group, Resource: "engines"}, name)
+}
+
+func (o *memoryObjectDB) GetEngine(name string) (*longhorn.Engine, error) {
+	resultRO, err := o.GetEngineRO(name)
+	if err != nil {
+		return nil, err
+	}
+	return resultRO.DeepCopy(), nil
+}
+
+func (o *memoryObjectDB) GetReplicaRO(name string) (*longhorn.Replica, error) {
+	if r, ok := o.replicas[name]; ok {
+		return r, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: longhorn.SchemeGroupVersion.Group, Resource: "replicas"}, name)
+}
+
+func (o *memoryObjectDB) GetReplica(name string) (*longhorn.Replica, error) {
+	resultRO, err := o.GetReplicaRO(name)
+	if err != nil {
+		return nil, err
+	}
+	return resultRO.DeepCopy(), nil
+}
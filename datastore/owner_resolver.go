@@ -0,0 +1,201 @@
+package datastore
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// WorkloadRef is the top-level controller-managed workload a Longhorn volume
+// was provisioned for, as resolved by walking OwnerReferences up from the
+// Pod that mounts it (Pod -> ReplicaSet -> Deployment, Pod -> StatefulSet,
+// Pod -> DaemonSet, Pod -> Job -> CronJob).
+type WorkloadRef struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+// OwnerResolver walks OwnerReferences chains across the native Kubernetes
+// workload types to find the top-level object that ultimately owns a Pod (and
+// therefore the PVC/volume it mounts). Resolutions are cached by UID since
+// the chain above a Pod rarely changes once created.
+type OwnerResolver struct {
+	podLister         corelisters.PodLister
+	replicaSetLister  appslisters.ReplicaSetLister
+	deploymentLister  appslisters.DeploymentLister
+	statefulSetLister appslisters.StatefulSetLister
+	daemonSetLister   appslisters.DaemonSetLister
+	jobLister         batchlisters.JobLister
+
+	mu    sync.RWMutex
+	cache map[ktypes.UID]*WorkloadRef
+}
+
+// NewOwnerResolver wires up the resolver against the core/apps/batch
+// informers the manager already starts for node and pod reconciliation.
+func NewOwnerResolver(
+	podLister corelisters.PodLister,
+	replicaSetLister appslisters.ReplicaSetLister,
+	deploymentLister appslisters.DeploymentLister,
+	statefulSetLister appslisters.StatefulSetLister,
+	daemonSetLister appslisters.DaemonSetLister,
+	jobLister batchlisters.JobLister,
+) *OwnerResolver {
+	return &OwnerResolver{
+		podLister:         podLister,
+		replicaSetLister:  replicaSetLister,
+		deploymentLister:  deploymentLister,
+		statefulSetLister: statefulSetLister,
+		daemonSetLister:   daemonSetLister,
+		jobLister:         jobLister,
+		cache:             map[ktypes.UID]*WorkloadRef{},
+	}
+}
+
+// InvalidateCache drops the cached resolution for uid. Call it from the
+// relevant informer's UpdateFunc/DeleteFunc so a cache entry doesn't survive
+// a Pod's owner being rewritten (e.g. adopted by a different controller) or
+// the Pod being deleted.
+func (r *OwnerResolver) InvalidateCache(uid ktypes.UID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, uid)
+}
+
+func (r *OwnerResolver) cached(uid ktypes.UID) (*WorkloadRef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ref, ok := r.cache[uid]
+	return ref, ok
+}
+
+func (r *OwnerResolver) remember(uid ktypes.UID, ref *WorkloadRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[uid] = ref
+}
+
+// maxOwnerChainDepth guards against an (invalid) OwnerReferences cycle
+// turning this into an infinite loop.
+const maxOwnerChainDepth = 10
+
+// Resolve walks the OwnerReferences chain starting from obj (a Pod, or
+// anything metav1.Object can read owner references from) and returns the
+// top-level workload it found. Only the first owner reference at each level
+// is followed, and every lookup uses obj's namespace. An object with no
+// owner references returns (nil, nil), since "no workload owns this" is an
+// expected outcome for bare Pods or directly-created PVCs. A broken chain
+// (a ReplicaSet or Job parent that can't be found) returns a WorkloadRef for
+// the nearest owner by reference rather than an error. A chain deeper than
+// maxOwnerChainDepth returns an error.
+func (r *OwnerResolver) Resolve(obj metav1.Object) (*WorkloadRef, error) {
+	uid := ktypes.UID(obj.GetUID())
+	if uid != "" {
+		if ref, ok := r.cached(uid); ok {
+			return ref, nil
+		}
+	}
+
+	ref, err := r.resolveChain(obj.GetNamespace(), obj.GetOwnerReferences(), 0)
+	if err != nil {
+		return nil, err
+	}
+	if uid != "" {
+		r.remember(uid, ref)
+	}
+	return ref, nil
+}
+
+func (r *OwnerResolver) resolveChain(namespace string, owners []metav1.OwnerReference, depth int) (*WorkloadRef, error) {
+	if len(owners) == 0 {
+		return nil, nil
+	}
+	if depth > maxOwnerChainDepth {
+		return nil, errors.Errorf("owner reference chain in namespace %v exceeded max depth %v, possible cycle", namespace, maxOwnerChainDepth)
+	}
+
+	owner := owners[0]
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := r.replicaSetLister.ReplicaSets(namespace).Get(owner.Name)
+		if err != nil {
+			// Broken chain (ReplicaSet already garbage collected): report what we know.
+			return &WorkloadRef{Kind: owner.Kind, Name: owner.Name, Namespace: namespace}, nil
+		}
+		if next, err := r.resolveChain(namespace, rs.OwnerReferences, depth+1); err == nil && next != nil {
+			return next, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &WorkloadRef{Kind: "ReplicaSet", Name: rs.Name, Namespace: namespace}, nil
+	case "Deployment":
+		return &WorkloadRef{Kind: "Deployment", Name: owner.Name, Namespace: namespace}, nil
+	case "StatefulSet":
+		return &WorkloadRef{Kind: "StatefulSet", Name: owner.Name, Namespace: namespace}, nil
+	case "DaemonSet":
+		return &WorkloadRef{Kind: "DaemonSet", Name: owner.Name, Namespace: namespace}, nil
+	case "Job":
+		j, err := r.jobLister.Jobs(namespace).Get(owner.Name)
+		if err != nil {
+			return &WorkloadRef{Kind: owner.Kind, Name: owner.Name, Namespace: namespace}, nil
+		}
+		if next, err := r.resolveChain(namespace, j.OwnerReferences, depth+1); err == nil && next != nil {
+			return next, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &WorkloadRef{Kind: "Job", Name: j.Name, Namespace: namespace}, nil
+	case "CronJob":
+		return &WorkloadRef{Kind: "CronJob", Name: owner.Name, Namespace: namespace}, nil
+	default:
+		// Unrecognized owner kind: report the nearest known owner rather than
+		// erroring, since this is a best-effort enrichment.
+		return &WorkloadRef{Kind: owner.Kind, Name: owner.Name, Namespace: namespace}, nil
+	}
+}
+
+// GetVolumeWorkload resolves the workload that owns the Pod currently using
+// the given volume (if any). volumeName is the Longhorn Volume name, which
+// is also the bound PV name, not the PVC's own ClaimName, so this first
+// finds the PVC whose Spec.VolumeName matches it, then scans Pods in that
+// PVC's namespace for a matching PersistentVolumeClaim volume source and
+// walks its owner chain. Returns (nil, nil) if no PVC is bound to the
+// volume, or no Pod currently mounts that PVC.
+func (s *DataStore) GetVolumeWorkload(volumeName string) (*WorkloadRef, error) {
+	pvcs, err := s.pvcLister.PersistentVolumeClaims(metav1.NamespaceAll).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var pvc *v1.PersistentVolumeClaim
+	for _, candidate := range pvcs {
+		if candidate.Spec.VolumeName == volumeName {
+			pvc = candidate
+			break
+		}
+	}
+	if pvc == nil {
+		return nil, nil
+	}
+
+	pods, err := s.ownerResolver.podLister.Pods(pvc.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return s.ownerResolver.Resolve(pod)
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,85 @@
+package engineapi
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// BackupStoreDriver is the interface a backup target URL scheme must
+// implement to be usable from BackupCreate/BackupRestore/BackupList. Each
+// driver owns the wire format for its remote (nfs, s3, azblob, cifs); the
+// engine only deals in backup URLs and Backup metadata.
+type BackupStoreDriver interface {
+	// CreateBackup uploads the snapshot's data to the target and returns the
+	// backup URL that later identifies it to Restore/List/Delete.
+	CreateBackup(target string, cred *BackupTargetCredential, snapshotName, volumeName string, labels map[string]string) (string, error)
+	RestoreBackup(backupURL string, cred *BackupTargetCredential) error
+	ListBackups(target string, cred *BackupTargetCredential) ([]*Backup, error)
+	DeleteBackup(backupURL string, cred *BackupTargetCredential) error
+}
+
+// backupStoreDrivers is keyed by URL scheme (the part before "://") and
+// populated by each driver's init() via RegisterBackupStoreDriver, mirroring
+// how backupstore itself dispatches nfs/s3/azblob/cifs targets.
+var backupStoreDrivers = map[string]BackupStoreDriver{}
+
+// RegisterBackupStoreDriver makes a driver available for backup targets
+// whose URL scheme matches. Intended to be called from a driver package's
+// init(), e.g. `engineapi.RegisterBackupStoreDriver("s3", &s3Driver{})`.
+func RegisterBackupStoreDriver(scheme string, driver BackupStoreDriver) {
+	backupStoreDrivers[scheme] = driver
+}
+
+func getBackupStoreDriver(target string) (BackupStoreDriver, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid backup target '%s'", target)
+	}
+	driver, ok := backupStoreDrivers[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported backup target scheme '%s' for target '%s'", u.Scheme, target)
+	}
+	return driver, nil
+}
+
+// BackupTargetCredential carries the secret material a backup target driver
+// needs to authenticate against its remote (S3 access keys, CIFS
+// username/password, and so on). Fields unused by a given driver are left
+// zero-valued.
+type BackupTargetCredential struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Username        string
+	Password        string
+}
+
+// CredentialFromMap adapts the map[string]string shape
+// DataStore.GetCredentialFromBackupTarget returns (secret data keyed by the
+// same AWS_*/CIFS_* names the backupstore CLI reads from its own
+// environment) into the typed BackupTargetCredential a BackupStoreDriver
+// expects, so callers don't have to know engineapi's field names. A nil or
+// empty map (no CredentialSecret configured) yields a zero-valued
+// credential, which every driver treats as "use the ambient environment".
+func CredentialFromMap(m map[string]string) *BackupTargetCredential {
+	return &BackupTargetCredential{
+		AccessKeyID:     m["AWS_ACCESS_KEY_ID"],
+		SecretAccessKey: m["AWS_SECRET_ACCESS_KEY"],
+		Username:        m["CIFS_USERNAME"],
+		Password:        m["CIFS_PASSWORD"],
+	}
+}
+
+// Backup mirrors the subset of backupstore's config metadata the manager
+// needs to drive scheduled backups and restores without understanding any
+// particular backup target's on-disk layout.
+type Backup struct {
+	Name         string
+	URL          string
+	VolumeName   string
+	SnapshotName string
+	CreatedAt    string
+	Size         int64
+	Labels       map[string]string
+}
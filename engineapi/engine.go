@@ -2,6 +2,7 @@ package engineapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -15,15 +16,45 @@ type EngineCollection struct{}
 type Engine struct {
 	name string
 	cURL string
+
+	// backingFilePath is the node-local path PrepareBackingFile downloaded
+	// request.BackingFile to, set by NewEngineClient before returning so the
+	// caller launching the engine controller can read it back via
+	// BackingFilePath instead of it being thrown away.
+	backingFilePath string
 }
 
 func (c *EngineCollection) NewEngineClient(request *EngineClientRequest) (EngineClient, error) {
+	var backingFilePath string
+	if request.BackingFile != nil {
+		if request.DataEngine == DataEngineV2 {
+			return nil, errors.Errorf("backing files are not yet supported on the v2 (SPDK) data engine for volume '%s'", request.VolumeName)
+		}
+		path, err := (&Engine{name: request.VolumeName}).PrepareBackingFile(request.BackingFile.URL, request.BackingFile.Checksum)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to prepare backing file for volume '%s'", request.VolumeName)
+		}
+		backingFilePath = path
+	}
+	if request.DataEngine == DataEngineV2 {
+		return newSPDKEngine(request)
+	}
+	if request.ControllerGRPCAddress != "" {
+		return newGRPCEngine(request, backingFilePath)
+	}
 	return &Engine{
-		name: request.VolumeName,
-		cURL: request.ControllerURL,
+		name:            request.VolumeName,
+		cURL:            request.ControllerURL,
+		backingFilePath: backingFilePath,
 	}, nil
 }
 
+// BackingFilePath returns the node-local path PrepareBackingFile downloaded
+// this engine's backing file to, or "" if the volume has none.
+func (e *Engine) BackingFilePath() string {
+	return e.backingFilePath
+}
+
 func (e *Engine) Name() string {
 	return e.name
 }
@@ -106,3 +137,94 @@ func (e *Engine) info() (*Volume, error) {
 	}
 	return info, nil
 }
+
+func (e *Engine) SnapshotCreate(name string, labels map[string]string) (string, error) {
+	args := []string{"--url", e.cURL, "snapshot", "create"}
+	for k, v := range labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, v))
+	}
+	if name != "" {
+		args = append(args, name)
+	}
+	output, err := util.Execute("longhorn", args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create snapshot '%s' for controller '%s'", name, e.name)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (e *Engine) SnapshotList() (map[string]*Snapshot, error) {
+	output, err := util.Execute("longhorn", "--url", e.cURL, "snapshot", "ls")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list snapshots for controller '%s'", e.name)
+	}
+	snapshots := make(map[string]*Snapshot)
+	if err := json.Unmarshal([]byte(output), &snapshots); err != nil {
+		return nil, errors.Wrapf(err, "cannot decode snapshot list: %v", output)
+	}
+	return snapshots, nil
+}
+
+func (e *Engine) SnapshotRevert(name string) error {
+	if _, err := util.Execute("longhorn", "--url", e.cURL, "snapshot", "revert", name); err != nil {
+		return errors.Wrapf(err, "failed to revert snapshot '%s' for controller '%s'", name, e.name)
+	}
+	return nil
+}
+
+func (e *Engine) SnapshotDelete(name string) error {
+	if _, err := util.Execute("longhorn", "--url", e.cURL, "snapshot", "rm", name); err != nil {
+		return errors.Wrapf(err, "failed to delete snapshot '%s' for controller '%s'", name, e.name)
+	}
+	return nil
+}
+
+func (e *Engine) BackupCreate(snapshotName, backupTarget string, labels map[string]string, credential map[string]string) (string, error) {
+	driver, err := getBackupStoreDriver(backupTarget)
+	if err != nil {
+		return "", err
+	}
+	backupURL, err := driver.CreateBackup(backupTarget, CredentialFromMap(credential), snapshotName, e.name, labels)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create backup of snapshot '%s' to target '%s'", snapshotName, backupTarget)
+	}
+	return backupURL, nil
+}
+
+func (e *Engine) BackupRestore(backupURL string, credential map[string]string) error {
+	driver, err := getBackupStoreDriver(backupURL)
+	if err != nil {
+		return err
+	}
+	if err := driver.RestoreBackup(backupURL, CredentialFromMap(credential)); err != nil {
+		return errors.Wrapf(err, "failed to restore backup '%s' to controller '%s'", backupURL, e.name)
+	}
+	return nil
+}
+
+// Capabilities reports the feature set the legacy CLI-backed engine
+// supports. It's the baseline every other EngineClient implementation is
+// compared against: everything is supported.
+func (e *Engine) Capabilities() map[Capability]bool {
+	return map[Capability]bool{
+		CapabilityBackup:        true,
+		CapabilitySnapshotPurge: true,
+		CapabilityBackingFile:   true,
+	}
+}
+
+func (e *Engine) BackupList(backupTarget string, credential map[string]string) (map[string]*Backup, error) {
+	driver, err := getBackupStoreDriver(backupTarget)
+	if err != nil {
+		return nil, err
+	}
+	list, err := driver.ListBackups(backupTarget, CredentialFromMap(credential))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list backups on target '%s'", backupTarget)
+	}
+	backups := make(map[string]*Backup, len(list))
+	for _, b := range list {
+		backups[b.Name] = b
+	}
+	return backups, nil
+}
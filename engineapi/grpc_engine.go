@@ -0,0 +1,285 @@
+package engineapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/longhorn/longhorn-engine/controller/client"
+)
+
+// grpcDialTimeout is the deadline grpcContext puts on each RPC GRPCEngine
+// issues, so a dead controller doesn't hang a call. The initial dial in
+// getOrDialControllerClient is not bounded by it.
+const grpcDialTimeout = 5 * time.Second
+
+// controllerGRPCClient is the subset of *client.ControllerClient's method set
+// GRPCEngine drives. Declaring it lets tests substitute an in-process fake
+// for the real gRPC connection, so GRPCEngine's request/response handling
+// can be exercised without dialing a controller or shelling out to any CLI.
+type controllerGRPCClient interface {
+	ReplicaList(ctx context.Context) ([]*client.Replica, error)
+	ReplicaCreate(ctx context.Context, url string) error
+	ReplicaDelete(ctx context.Context, url string) error
+	VolumeGet(ctx context.Context) (*client.Volume, error)
+	VolumeSnapshot(ctx context.Context, name string, labels map[string]string) (string, error)
+	SnapshotList(ctx context.Context) ([]*client.Snapshot, error)
+	VolumeRevert(ctx context.Context, name string) error
+	SnapshotDelete(ctx context.Context, name string) error
+}
+
+// grpcConnPool keeps one controller client per address alive across
+// NewEngineClient calls instead of dialing fresh for every RPC, since the
+// longhorn-engine controller client wraps a long-lived gRPC connection.
+var (
+	grpcConnPoolMu sync.Mutex
+	grpcConnPool   = map[string]*client.ControllerClient{}
+)
+
+func getOrDialControllerClient(address string) (*client.ControllerClient, error) {
+	grpcConnPoolMu.Lock()
+	defer grpcConnPoolMu.Unlock()
+
+	if c, ok := grpcConnPool[address]; ok {
+		return c, nil
+	}
+	c, err := client.NewControllerClient(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial longhorn-engine controller at %v", address)
+	}
+	grpcConnPool[address] = c
+	return c, nil
+}
+
+// GRPCEngine talks to the longhorn-engine controller directly over gRPC
+// instead of shelling out to the `longhorn` CLI binary the way Engine does.
+// It's selected by EngineCollection.NewEngineClient when the caller supplies
+// a ControllerGRPCAddress.
+type GRPCEngine struct {
+	name    string
+	address string
+	client  controllerGRPCClient
+
+	// backingFilePath is the node-local path NewEngineClient's backing-file
+	// prep downloaded request.BackingFile to, if any. See
+	// Engine.backingFilePath for why this is threaded through instead of
+	// discarded.
+	backingFilePath string
+}
+
+func newGRPCEngine(request *EngineClientRequest, backingFilePath string) (EngineClient, error) {
+	c, err := getOrDialControllerClient(request.ControllerGRPCAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCEngine{
+		name:            request.VolumeName,
+		address:         request.ControllerGRPCAddress,
+		client:          c,
+		backingFilePath: backingFilePath,
+	}, nil
+}
+
+func (e *GRPCEngine) Name() string {
+	return e.name
+}
+
+// BackingFilePath returns the node-local path this engine's backing file was
+// downloaded to, or "" if the volume has none.
+func (e *GRPCEngine) BackingFilePath() string {
+	return e.backingFilePath
+}
+
+func grpcContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), grpcDialTimeout)
+}
+
+// ReplicaList issues a ReplicaList RPC instead of parsing the free-form `ls`
+// CLI output the way Engine.ReplicaList does, so a replica whose address
+// happens to look like a status line can't be misparsed.
+func (e *GRPCEngine) ReplicaList() (map[string]*Replica, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	reply, err := e.client.ReplicaList(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list replicas from controller '%s' over gRPC", e.name)
+	}
+
+	replicas := make(map[string]*Replica, len(reply))
+	for _, r := range reply {
+		replicas[r.Address] = &Replica{
+			URL:  r.Address,
+			Mode: grpcReplicaModeToMode(r.Mode),
+		}
+	}
+	return replicas, nil
+}
+
+// grpcReplicaModeToMode converts the typed mode enum the gRPC API returns
+// into the same ReplicaMode values the CLI transport produces, so callers
+// don't have to care which EngineClient implementation they're talking to.
+func grpcReplicaModeToMode(mode client.ReplicaMode) ReplicaMode {
+	switch mode {
+	case client.ReplicaModeRW:
+		return ReplicaModeRW
+	case client.ReplicaModeWO:
+		return ReplicaModeWO
+	default:
+		return ReplicaModeERR
+	}
+}
+
+func (e *GRPCEngine) ReplicaAdd(url string) error {
+	if err := ValidateReplicaURL(url); err != nil {
+		return err
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.ReplicaCreate(ctx, url); err != nil {
+		return errors.Wrapf(err, "failed to add replica address='%s' to controller '%s' over gRPC", url, e.name)
+	}
+	return nil
+}
+
+func (e *GRPCEngine) ReplicaRemove(url string) error {
+	if err := ValidateReplicaURL(url); err != nil {
+		return err
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.ReplicaDelete(ctx, url); err != nil {
+		return errors.Wrapf(err, "failed to rm replica address='%s' from controller '%s' over gRPC", url, e.name)
+	}
+	return nil
+}
+
+func (e *GRPCEngine) Endpoint() string {
+	info, err := e.info()
+	if err != nil {
+		return ""
+	}
+	return info.Endpoint
+}
+
+func (e *GRPCEngine) info() (*Volume, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	v, err := e.client.VolumeGet(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get volume info over gRPC")
+	}
+	return &Volume{
+		Name:     v.Name,
+		Endpoint: v.Endpoint,
+	}, nil
+}
+
+func (e *GRPCEngine) SnapshotCreate(name string, labels map[string]string) (string, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	created, err := e.client.VolumeSnapshot(ctx, name, labels)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create snapshot '%s' for controller '%s' over gRPC", name, e.name)
+	}
+	return created, nil
+}
+
+func (e *GRPCEngine) SnapshotList() (map[string]*Snapshot, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	reply, err := e.client.SnapshotList(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list snapshots for controller '%s' over gRPC", e.name)
+	}
+
+	snapshots := make(map[string]*Snapshot, len(reply))
+	for _, s := range reply {
+		snapshots[s.Name] = &Snapshot{
+			Name:    s.Name,
+			Parent:  s.Parent,
+			Removed: s.Removed,
+			Size:    s.Size,
+			Created: s.Created,
+			Labels:  s.Labels,
+		}
+	}
+	return snapshots, nil
+}
+
+func (e *GRPCEngine) SnapshotRevert(name string) error {
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.VolumeRevert(ctx, name); err != nil {
+		return errors.Wrapf(err, "failed to revert snapshot '%s' for controller '%s' over gRPC", name, e.name)
+	}
+	return nil
+}
+
+func (e *GRPCEngine) SnapshotDelete(name string) error {
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.SnapshotDelete(ctx, name); err != nil {
+		return errors.Wrapf(err, "failed to delete snapshot '%s' for controller '%s' over gRPC", name, e.name)
+	}
+	return nil
+}
+
+// BackupCreate, BackupRestore, and BackupList go straight to the pluggable
+// backupstore driver rather than through the controller, exactly like
+// Engine's implementation: backup target I/O happens client-side against
+// the remote, not inside the longhorn-engine controller process.
+func (e *GRPCEngine) BackupCreate(snapshotName, backupTarget string, labels map[string]string, credential map[string]string) (string, error) {
+	driver, err := getBackupStoreDriver(backupTarget)
+	if err != nil {
+		return "", err
+	}
+	backupURL, err := driver.CreateBackup(backupTarget, CredentialFromMap(credential), snapshotName, e.name, labels)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create backup of snapshot '%s' to target '%s'", snapshotName, backupTarget)
+	}
+	return backupURL, nil
+}
+
+func (e *GRPCEngine) BackupRestore(backupURL string, credential map[string]string) error {
+	driver, err := getBackupStoreDriver(backupURL)
+	if err != nil {
+		return err
+	}
+	if err := driver.RestoreBackup(backupURL, CredentialFromMap(credential)); err != nil {
+		return errors.Wrapf(err, "failed to restore backup '%s' to controller '%s'", backupURL, e.name)
+	}
+	return nil
+}
+
+// Capabilities reports the feature set the gRPC-backed v1 engine supports.
+// It shares the same feature set as the CLI-backed Engine since both talk
+// to the same tgt-based longhorn-engine controller.
+func (e *GRPCEngine) Capabilities() map[Capability]bool {
+	return map[Capability]bool{
+		CapabilityBackup:        true,
+		CapabilitySnapshotPurge: true,
+		CapabilityBackingFile:   true,
+	}
+}
+
+func (e *GRPCEngine) BackupList(backupTarget string, credential map[string]string) (map[string]*Backup, error) {
+	driver, err := getBackupStoreDriver(backupTarget)
+	if err != nil {
+		return nil, err
+	}
+	list, err := driver.ListBackups(backupTarget, CredentialFromMap(credential))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list backups on target '%s'", backupTarget)
+	}
+	backups := make(map[string]*Backup, len(list))
+	for _, b := range list {
+		backups[b.Name] = b
+	}
+	return backups, nil
+}
@@ -0,0 +1,107 @@
+package engineapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/longhorn/longhorn-engine/controller/client"
+)
+
+// fakeControllerGRPCClient is an in-process stand-in for
+// *client.ControllerClient, so GRPCEngine's RPC handling can be exercised
+// without dialing a real longhorn-engine controller or shelling out to any
+// CLI binary.
+type fakeControllerGRPCClient struct {
+	replicas []*client.Replica
+}
+
+func (f *fakeControllerGRPCClient) ReplicaList(ctx context.Context) ([]*client.Replica, error) {
+	return f.replicas, nil
+}
+
+func (f *fakeControllerGRPCClient) ReplicaCreate(ctx context.Context, url string) error {
+	f.replicas = append(f.replicas, &client.Replica{Address: url, Mode: client.ReplicaModeRW})
+	return nil
+}
+
+func (f *fakeControllerGRPCClient) ReplicaDelete(ctx context.Context, url string) error {
+	kept := f.replicas[:0]
+	for _, r := range f.replicas {
+		if r.Address != url {
+			kept = append(kept, r)
+		}
+	}
+	f.replicas = kept
+	return nil
+}
+
+func (f *fakeControllerGRPCClient) VolumeGet(ctx context.Context) (*client.Volume, error) {
+	return &client.Volume{}, nil
+}
+
+func (f *fakeControllerGRPCClient) VolumeSnapshot(ctx context.Context, name string, labels map[string]string) (string, error) {
+	return name, nil
+}
+
+func (f *fakeControllerGRPCClient) SnapshotList(ctx context.Context) ([]*client.Snapshot, error) {
+	return nil, nil
+}
+
+func (f *fakeControllerGRPCClient) VolumeRevert(ctx context.Context, name string) error {
+	return nil
+}
+
+func (f *fakeControllerGRPCClient) SnapshotDelete(ctx context.Context, name string) error {
+	return nil
+}
+
+func TestGRPCEngineReplicaList(t *testing.T) {
+	fake := &fakeControllerGRPCClient{
+		replicas: []*client.Replica{
+			{Address: "tcp://replica-1:9502", Mode: client.ReplicaModeRW},
+			{Address: "tcp://replica-2:9502", Mode: client.ReplicaModeWO},
+		},
+	}
+	e := &GRPCEngine{name: "test-vol", client: fake}
+
+	replicas, err := e.ReplicaList()
+	if err != nil {
+		t.Fatalf("ReplicaList failed: %v", err)
+	}
+	if len(replicas) != 2 {
+		t.Fatalf("expected 2 replicas, got %v", len(replicas))
+	}
+	if replicas["tcp://replica-1:9502"].Mode != ReplicaModeRW {
+		t.Fatalf("expected replica-1 to be RW, got %v", replicas["tcp://replica-1:9502"].Mode)
+	}
+	if replicas["tcp://replica-2:9502"].Mode != ReplicaModeWO {
+		t.Fatalf("expected replica-2 to be WO, got %v", replicas["tcp://replica-2:9502"].Mode)
+	}
+}
+
+func TestGRPCEngineReplicaAddRemove(t *testing.T) {
+	fake := &fakeControllerGRPCClient{}
+	e := &GRPCEngine{name: "test-vol", client: fake}
+
+	if err := e.ReplicaAdd("tcp://replica-1:9502"); err != nil {
+		t.Fatalf("ReplicaAdd failed: %v", err)
+	}
+	replicas, err := e.ReplicaList()
+	if err != nil {
+		t.Fatalf("ReplicaList failed: %v", err)
+	}
+	if len(replicas) != 1 {
+		t.Fatalf("expected 1 replica after add, got %v", len(replicas))
+	}
+
+	if err := e.ReplicaRemove("tcp://replica-1:9502"); err != nil {
+		t.Fatalf("ReplicaRemove failed: %v", err)
+	}
+	replicas, err = e.ReplicaList()
+	if err != nil {
+		t.Fatalf("ReplicaList failed: %v", err)
+	}
+	if len(replicas) != 0 {
+		t.Fatalf("expected 0 replicas after remove, got %v", len(replicas))
+	}
+}
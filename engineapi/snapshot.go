@@ -0,0 +1,15 @@
+package engineapi
+
+// Snapshot mirrors the metadata longhorn-engine's controller tracks for a
+// point-in-time snapshot of the volume, as returned by `snapshot ls`/the
+// gRPC SnapshotList RPC.
+type Snapshot struct {
+	Name        string
+	Parent      string
+	Children    map[string]bool
+	Removed     bool
+	UserCreated bool
+	Size        string
+	Created     string
+	Labels      map[string]string
+}
@@ -0,0 +1,177 @@
+package engineapi
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/longhorn/longhorn-spdk-engine/pkg/client"
+)
+
+// DataEngine selects which data-plane implementation NewEngineClient
+// constructs for a volume. v1 is the original iSCSI/tgt engine (either the
+// CLI-backed Engine or the gRPC-backed GRPCEngine, chosen by
+// ControllerGRPCAddress); v2 is the SPDK-backed engine.
+type DataEngine string
+
+const (
+	DataEngineV1 = DataEngine("v1")
+	DataEngineV2 = DataEngine("v2")
+)
+
+// Frontend identifies how a volume is exposed to its consumer, returned as
+// part of Volume so the manager and CSI plugin know which attach path to
+// use.
+type Frontend string
+
+const (
+	FrontendTGTBlockDev  = Frontend("tgt-blockdev")
+	FrontendNVMf         = Frontend("nvmf")
+	FrontendVhostUserBlk = Frontend("vhost-user-blk")
+)
+
+// Capability is a feature an EngineClient implementation may or may not
+// support, so callers can gate things like Backup or SnapshotPurge per
+// backend instead of assuming every EngineClient behaves like the v1
+// engine.
+type Capability string
+
+const (
+	CapabilityBackup        = Capability("backup")
+	CapabilitySnapshotPurge = Capability("snapshotPurge")
+	CapabilityBackingFile   = Capability("backingFile")
+)
+
+// SPDKEngine talks to the longhorn-spdk-engine gRPC service instead of the
+// tgt-based longhorn-engine controller, for volumes running on the v2
+// (SPDK) data plane.
+type SPDKEngine struct {
+	name    string
+	address string
+	client  *client.SPDKClient
+}
+
+func newSPDKEngine(request *EngineClientRequest) (EngineClient, error) {
+	c, err := client.NewSPDKClient(request.ControllerGRPCAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial longhorn-spdk-engine at %v", request.ControllerGRPCAddress)
+	}
+	return &SPDKEngine{
+		name:    request.VolumeName,
+		address: request.ControllerGRPCAddress,
+		client:  c,
+	}, nil
+}
+
+func (e *SPDKEngine) Name() string {
+	return e.name
+}
+
+func (e *SPDKEngine) ReplicaList() (map[string]*Replica, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	reply, err := e.client.ReplicaList(ctx, e.name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list replicas from SPDK engine '%s'", e.name)
+	}
+	replicas := make(map[string]*Replica, len(reply))
+	for _, r := range reply {
+		replicas[r.Address] = &Replica{
+			URL:  r.Address,
+			Mode: grpcReplicaModeToMode(r.Mode),
+		}
+	}
+	return replicas, nil
+}
+
+func (e *SPDKEngine) ReplicaAdd(url string) error {
+	if err := ValidateReplicaURL(url); err != nil {
+		return err
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.ReplicaAdd(ctx, e.name, url); err != nil {
+		return errors.Wrapf(err, "failed to add replica address='%s' to SPDK engine '%s'", url, e.name)
+	}
+	return nil
+}
+
+func (e *SPDKEngine) ReplicaRemove(url string) error {
+	if err := ValidateReplicaURL(url); err != nil {
+		return err
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	if err := e.client.ReplicaRemove(ctx, e.name, url); err != nil {
+		return errors.Wrapf(err, "failed to rm replica address='%s' from SPDK engine '%s'", url, e.name)
+	}
+	return nil
+}
+
+// Endpoint returns the NVMe-oF subsystem NQN or vhost socket path the
+// consumer should attach to, depending on the volume's Frontend.
+func (e *SPDKEngine) Endpoint() string {
+	info, err := e.info()
+	if err != nil {
+		return ""
+	}
+	return info.Endpoint
+}
+
+func (e *SPDKEngine) info() (*Volume, error) {
+	ctx, cancel := grpcContext()
+	defer cancel()
+
+	v, err := e.client.VolumeGet(ctx, e.name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get volume info from SPDK engine")
+	}
+	return &Volume{
+		Name:     v.Name,
+		Endpoint: v.Endpoint,
+		Frontend: Frontend(v.Frontend),
+	}, nil
+}
+
+// Capabilities reports the feature set the SPDK engine currently supports.
+// Backup, SnapshotPurge, and BackingFile aren't implemented against the v2
+// data plane yet, so they're left out until longhorn-spdk-engine grows them.
+func (e *SPDKEngine) Capabilities() map[Capability]bool {
+	return map[Capability]bool{
+		CapabilityBackup:        false,
+		CapabilitySnapshotPurge: false,
+		CapabilityBackingFile:   false,
+	}
+}
+
+// errSPDKUnsupported is returned by every EngineClient method the v2 (SPDK)
+// data plane doesn't implement yet, matching what Capabilities() already
+// reports for CapabilityBackup/CapabilitySnapshotPurge.
+var errSPDKUnsupported = errors.New("not supported on the v2 (SPDK) data plane")
+
+func (e *SPDKEngine) SnapshotCreate(name string, labels map[string]string) (string, error) {
+	return "", errSPDKUnsupported
+}
+
+func (e *SPDKEngine) SnapshotList() (map[string]*Snapshot, error) {
+	return nil, errSPDKUnsupported
+}
+
+func (e *SPDKEngine) SnapshotRevert(name string) error {
+	return errSPDKUnsupported
+}
+
+func (e *SPDKEngine) SnapshotDelete(name string) error {
+	return errSPDKUnsupported
+}
+
+func (e *SPDKEngine) BackupCreate(snapshotName, backupTarget string, labels map[string]string, credential map[string]string) (string, error) {
+	return "", errSPDKUnsupported
+}
+
+func (e *SPDKEngine) BackupRestore(backupURL string, credential map[string]string) error {
+	return errSPDKUnsupported
+}
+
+func (e *SPDKEngine) BackupList(backupTarget string, credential map[string]string) (map[string]*Backup, error) {
+	return nil, errSPDKUnsupported
+}
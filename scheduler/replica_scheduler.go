@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+)
+
+// DiskSchedulingInfo is the capacity view NodeController hands the
+// scheduler for one disk, built from the disk's spec and the latest
+// DiskStatus syncDiskStatus computed.
+type DiskSchedulingInfo struct {
+	NodeID           string
+	DiskID           string
+	Zone             string
+	Region           string
+	StorageAvailable int64
+	StorageMaximum   int64
+	StorageReserved  int64
+	StorageScheduled int64
+}
+
+// ReplicaScheduler picks which disk a new replica should land on. Besides
+// the original binary schedulability check (does the disk have room), it
+// scores candidates by topology spread against a volume's existing
+// replicas, per ReplicaSoftAntiAffinity.
+type ReplicaScheduler struct {
+	ds *datastore.DataStore
+}
+
+func NewReplicaScheduler(ds *datastore.DataStore) *ReplicaScheduler {
+	return &ReplicaScheduler{ds: ds}
+}
+
+// GetDiskSchedulingInfo builds the capacity view for one disk from its spec
+// and current status.
+func (rs *ReplicaScheduler) GetDiskSchedulingInfo(disk types.DiskSpec, status *types.DiskStatus) (*DiskSchedulingInfo, error) {
+	return &DiskSchedulingInfo{
+		StorageAvailable: status.StorageAvailable,
+		StorageMaximum:   status.StorageMaximum,
+		StorageReserved:  disk.StorageReserved,
+		StorageScheduled: status.StorageScheduled,
+	}, nil
+}
+
+// IsSchedulableToDisk reports whether a replica of size (plus requestedSize
+// already accounted for by the caller, e.g. during a resize) fits on the
+// disk once its reserved storage and minimal-available-percentage floor are
+// honored.
+func (rs *ReplicaScheduler) IsSchedulableToDisk(size, requestedSize int64, info *DiskSchedulingInfo) bool {
+	availableAfter := info.StorageAvailable - requestedSize
+	usable := info.StorageMaximum - info.StorageReserved
+	if usable <= 0 {
+		return false
+	}
+	return availableAfter >= size
+}
+
+// ReplicaSoftAntiAffinity selects the granularity ScoreCandidates spreads
+// replicas across: prefer a disk in a zone/region/node/disk not already
+// hosting a replica of the volume, falling back to the next-best candidate
+// (and ultimately to "anywhere with room") rather than failing scheduling
+// outright when strict spread is infeasible.
+type ReplicaSoftAntiAffinity string
+
+const (
+	ReplicaSoftAntiAffinityZone   = ReplicaSoftAntiAffinity("zone")
+	ReplicaSoftAntiAffinityRegion = ReplicaSoftAntiAffinity("region")
+	ReplicaSoftAntiAffinityNode   = ReplicaSoftAntiAffinity("node")
+	ReplicaSoftAntiAffinityDisk   = ReplicaSoftAntiAffinity("disk")
+)
+
+// ScoreCandidates ranks candidateDisks for placing a new replica of
+// volumeName, preferring ones whose failure domain (per policy) isn't
+// already occupied by one of the volume's existing replicas. It returns the
+// candidates' disk IDs ordered best-first. Within the preferred and the
+// fallback group the order follows Go map iteration over candidateDisks, so
+// it is not deterministic.
+//
+// When every candidate collides with an existing replica's failure domain
+// (e.g. a 3-zone cluster already has one replica per zone), all candidates
+// score equally and the caller is expected to fall back to plain capacity
+// scheduling rather than fail outright -- strict spread is a preference,
+// not a hard requirement.
+func (rs *ReplicaScheduler) ScoreCandidates(volumeName string, policy ReplicaSoftAntiAffinity, candidateDisks map[string]*DiskSchedulingInfo) ([]string, error) {
+	existingReplicas, err := rs.ds.ListVolumeReplicas(volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	occupied := map[string]bool{}
+	for _, r := range existingReplicas {
+		if r.Spec.NodeID == "" {
+			continue
+		}
+		node, err := rs.ds.GetNode(r.Spec.NodeID)
+		if err != nil {
+			if datastore.ErrorIsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		occupied[rs.failureDomainKey(policy, node, r.Spec.DiskID)] = true
+	}
+
+	preferred := []string{}
+	fallback := []string{}
+	for diskID, info := range candidateDisks {
+		node, err := rs.ds.GetNode(info.NodeID)
+		if err != nil {
+			if datastore.ErrorIsNotFound(err) {
+				fallback = append(fallback, diskID)
+				continue
+			}
+			return nil, err
+		}
+		key := rs.failureDomainKey(policy, node, diskID)
+		if occupied[key] {
+			fallback = append(fallback, diskID)
+		} else {
+			preferred = append(preferred, diskID)
+		}
+	}
+
+	return append(preferred, fallback...), nil
+}
+
+func (rs *ReplicaScheduler) failureDomainKey(policy ReplicaSoftAntiAffinity, node *longhorn.Node, diskID string) string {
+	switch policy {
+	case ReplicaSoftAntiAffinityRegion:
+		return fmt.Sprintf("region:%v", node.Status.Region)
+	case ReplicaSoftAntiAffinityNode:
+		return fmt.Sprintf("node:%v", node.Name)
+	case ReplicaSoftAntiAffinityDisk:
+		return fmt.Sprintf("disk:%v:%v", node.Name, diskID)
+	case ReplicaSoftAntiAffinityZone:
+		fallthrough
+	default:
+		return fmt.Sprintf("zone:%v", node.Status.Zone)
+	}
+}